@@ -0,0 +1,239 @@
+package jsondiffgo
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// slot is one element of an array rebuilt from deltas. It is either an item
+// of the original array, possibly changed by a nested delta, or a value the
+// deltas inserted.
+type slot struct {
+	old   int // index in the original array, or -1 for an inserted value
+	value any // the inserted value when old < 0
+	delta any // nested delta of an original item, nil when unchanged
+}
+
+// arrayPlan resolves array deltas against an original array whose length is
+// unknown. The array they produce is items followed by the untouched original
+// items tail, tail+1, ...; deleted holds the original items the deltas
+// dropped, with the values they recorded.
+//
+// Deltas are applied the same way applyArrayPatch applies them, so indices in
+// a plan mean exactly what Patch would make of them.
+type arrayPlan struct {
+	items   []slot
+	tail    int
+	deleted map[int]any
+}
+
+func newArrayPlan() *arrayPlan {
+	return &arrayPlan{deleted: map[int]any{}}
+}
+
+// ensure materializes original items until the plan holds at least n items.
+func (p *arrayPlan) ensure(n int) {
+	for len(p.items) < n {
+		p.items = append(p.items, slot{old: p.tail})
+		p.tail++
+	}
+}
+
+// apply resolves the array delta d against the array the plan currently
+// produces.
+func (p *arrayPlan) apply(d map[string]any) error {
+	body := make(map[string]any, len(d))
+	for k, v := range d {
+		if k != "_t" {
+			body[k] = v
+		}
+	}
+	deletedIdx, moves, remaining, err := parseArrayDiff(body)
+	if err != nil {
+		return err
+	}
+
+	need := 0
+	for i := range deletedIdx {
+		need = max(need, i+1)
+	}
+	for _, m := range moves {
+		need = max(need, m.src+1)
+	}
+	p.ensure(need)
+
+	// Deletions and moves address positions before this delta.
+	kept := make([]int, 0, len(p.items))
+	items := make([]slot, 0, len(p.items))
+	for i, s := range p.items {
+		if _, isDel := deletedIdx[i]; !isDel {
+			kept = append(kept, i)
+			items = append(items, s)
+			continue
+		}
+		if s.old < 0 {
+			continue
+		}
+		marker, _ := body["_"+strconv.Itoa(i)].([]any)
+		var recorded any
+		if len(marker) > 0 {
+			recorded = marker[0]
+		}
+		if p.deleted[s.old], err = unpatchValue(recorded, s.delta); err != nil {
+			return err
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].dest < moves[j].dest })
+	for _, m := range moves {
+		cur := slices.Index(kept, m.src)
+		if cur == -1 {
+			continue
+		}
+		s := items[cur]
+		items = slices.Delete(items, cur, cur+1)
+		kept = slices.Delete(kept, cur, cur+1)
+		dest := min(max(m.dest, 0), len(items))
+		items = slices.Insert(items, dest, s)
+		kept = slices.Insert(kept, dest, m.src)
+	}
+	p.items = items
+
+	type op struct {
+		idx int
+		val any
+	}
+	ops := make([]op, 0, len(remaining))
+	for k, v := range remaining {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 {
+			return fmt.Errorf("%w: unexpected array key %q", ErrInvalidDelta, k)
+		}
+		ops = append(ops, op{idx: idx, val: v})
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].idx < ops[j].idx })
+	for _, o := range ops {
+		switch deltaKindOf(o.val) {
+		case kindAdd:
+			p.ensure(o.idx)
+			p.items = slices.Insert(p.items, o.idx, slot{old: -1, value: o.val.([]any)[0]})
+		case kindReplace:
+			p.ensure(o.idx + 1)
+			s := p.items[o.idx]
+			if s.old >= 0 {
+				if p.deleted[s.old], err = unpatchValue(o.val.([]any)[0], s.delta); err != nil {
+					return err
+				}
+			}
+			p.items[o.idx] = slot{old: -1, value: o.val.([]any)[1]}
+		case kindObject, kindArray:
+			p.ensure(o.idx + 1)
+			s := &p.items[o.idx]
+			if s.old >= 0 {
+				s.delta, err = composeValue(s.delta, o.val)
+			} else {
+				s.value, err = applyDelta(s.value, o.val)
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unsupported array operation at %d", ErrInvalidDelta, o.idx)
+		}
+	}
+	return nil
+}
+
+// delta encodes the plan as a single array delta from the original array to
+// the one the plan produces. It returns nil when nothing changes.
+func (p *arrayPlan) delta() map[string]any {
+	out := map[string]any{}
+	for i, v := range p.deleted {
+		out["_"+strconv.Itoa(i)] = []any{v, float64(0), float64(0)}
+	}
+
+	// After deletions Patch holds the survivors in original order; moves must
+	// bring them into the order of the plan.
+	var order []int
+	for _, s := range p.items {
+		if s.old >= 0 {
+			order = append(order, s.old)
+		}
+	}
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	for dest, src := range moveSequence(sorted, order) {
+		out["_"+strconv.Itoa(src)] = []any{"", float64(dest), float64(3)}
+	}
+
+	for i, s := range p.items {
+		switch {
+		case s.old < 0:
+			out[strconv.Itoa(i)] = []any{s.value}
+		case s.delta != nil:
+			out[strconv.Itoa(i)] = s.delta
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	out["_t"] = "a"
+	return out
+}
+
+// reverse encodes the plan as an array delta that turns the array the plan
+// produces back into the original one. Nested deltas are reversed too.
+func (p *arrayPlan) reverse() (map[string]any, error) {
+	out := map[string]any{}
+	var order []int
+	pos := map[int]int{}
+	for i, s := range p.items {
+		if s.old < 0 {
+			out["_"+strconv.Itoa(i)] = []any{s.value, float64(0), float64(0)}
+			continue
+		}
+		order = append(order, s.old)
+		pos[s.old] = i
+		if s.delta != nil {
+			r, err := reverseDelta(s.delta)
+			if err != nil {
+				return nil, err
+			}
+			out[strconv.Itoa(s.old)] = r
+		}
+	}
+
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	for dest, old := range moveSequence(order, sorted) {
+		out["_"+strconv.Itoa(pos[old])] = []any{"", float64(dest), float64(3)}
+	}
+
+	for i, v := range p.deleted {
+		out[strconv.Itoa(i)] = []any{v}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	out["_t"] = "a"
+	return out, nil
+}
+
+// moveSequence returns the moves, keyed by destination, that turn the
+// sequence from into to when applied in ascending destination order as
+// applyArrayMoves does. Each move names the element it moves.
+func moveSequence(from, to []int) map[int]int {
+	moves := map[int]int{}
+	cur := slices.Clone(from)
+	for dest, want := range to {
+		if cur[dest] == want {
+			continue
+		}
+		at := slices.Index(cur[dest:], want) + dest
+		cur = slices.Delete(cur, at, at+1)
+		cur = slices.Insert(cur, dest, want)
+		moves[dest] = want
+	}
+	return moves
+}
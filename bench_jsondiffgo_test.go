@@ -4,6 +4,7 @@ package jsondiffgo
 import (
 	"encoding/json"
 	"os"
+	"runtime"
 	"testing"
 )
 
@@ -60,3 +61,108 @@ func BenchmarkDiff_Medium(b *testing.B) {
 	}
 	benchSink = res
 }
+
+func BenchmarkDiff_Big_Parallel(b *testing.B) {
+	data1, j1 := loadJSONFileOrSkip(b, "profile-data/ModernAtomic.json")
+	data2, j2 := loadJSONFileOrSkip(b, "profile-data/LegacyAtomic.json")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data1) + len(data2)))
+	b.ResetTimer()
+
+	var res map[string]any
+	for i := 0; i < b.N; i++ {
+		res = Diff(j1, j2, WithParallelism(runtime.GOMAXPROCS(0)))
+	}
+	benchSink = res
+}
+
+// BenchmarkDiff_Wide compares the sequential and parallel paths on a
+// generated document, so it runs without the downloaded profile data.
+func BenchmarkDiff_Wide(b *testing.B) {
+	j1 := wideDocument(2000, 0)
+	j2 := wideDocument(2000, 5)
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Sequential", nil},
+		{"Parallel", []Option{WithParallelism(runtime.GOMAXPROCS(0))}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var res map[string]any
+			for i := 0; i < b.N; i++ {
+				res = Diff(j1, j2, bc.opts...)
+			}
+			benchSink = res
+		})
+	}
+}
+
+// BenchmarkDiffStructs compares DiffStructs with the marshal/unmarshal round
+// trip it replaces.
+func BenchmarkDiffStructs(b *testing.B) {
+	type item struct {
+		ID    int               `json:"id"`
+		Name  string            `json:"name"`
+		Tags  []string          `json:"tags,omitempty"`
+		Attrs map[string]string `json:"attrs"`
+	}
+	mk := func(n int, suffix string) []item {
+		out := make([]item, n)
+		for i := range out {
+			out[i] = item{ID: i, Name: "item" + suffix, Tags: []string{"x", "y"}, Attrs: map[string]string{"k": "v"}}
+		}
+		return out
+	}
+	s1, s2 := mk(500, ""), mk(500, "")
+	s2[250].Name = "changed"
+
+	b.Run("Reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		var res map[string]any
+		for i := 0; i < b.N; i++ {
+			res, _ = DiffStructs(map[string]any{"items": s1}, map[string]any{"items": s2})
+		}
+		benchSink = res
+	})
+	b.Run("RoundTrip", func(b *testing.B) {
+		b.ReportAllocs()
+		var res map[string]any
+		for i := 0; i < b.N; i++ {
+			d1, _ := json.Marshal(map[string]any{"items": s1})
+			d2, _ := json.Marshal(map[string]any{"items": s2})
+			var j1, j2 any
+			_ = json.Unmarshal(d1, &j1)
+			_ = json.Unmarshal(d2, &j2)
+			res = Diff(j1, j2)
+		}
+		benchSink = res
+	})
+}
+
+// BenchmarkPatch_Scaled compares the copying Patch with PatchInPlace on the
+// big test documents repeated many times. PatchInPlace needs a fresh copy of
+// the document per iteration, which is excluded from the timing.
+func BenchmarkPatch_Scaled(b *testing.B) {
+	a, d := scaledBigJSON(b, 200)
+
+	b.Run("Copy", func(b *testing.B) {
+		b.ReportAllocs()
+		var res map[string]any
+		for i := 0; i < b.N; i++ {
+			res, _ = Patch(a, d)
+		}
+		benchSink = res
+	})
+	b.Run("InPlace", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			doc := deepCopy(a).(map[string]any)
+			b.StartTimer()
+			_ = PatchInPlace(doc, d)
+		}
+	})
+}
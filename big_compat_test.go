@@ -8,13 +8,19 @@ import (
 	"testing"
 )
 
-func mustReadJSON(t *testing.T, path string) any {
-	t.Helper()
+func mustReadFile(tb testing.TB, path string) []byte {
+	tb.Helper()
 	// #nosec G304 -- test helper reads local testdata paths
 	b, err := os.ReadFile(path)
 	if err != nil {
-		t.Fatalf("read %s: %v", path, err)
+		tb.Fatalf("read %s: %v", path, err)
 	}
+	return b
+}
+
+func mustReadJSON(t *testing.T, path string) any {
+	t.Helper()
+	b := mustReadFile(t, path)
 	var v any
 	if err := json.Unmarshal(b, &v); err != nil {
 		t.Fatalf("parse %s: %v", path, err)
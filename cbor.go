@@ -0,0 +1,419 @@
+package jsondiffgo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// ErrInvalidCBOR is returned by UnmarshalDeltaCBOR for input that is not
+// well-formed CBOR or does not describe a JSON value.
+var ErrInvalidCBOR = errors.New("jsondiffgo: invalid CBOR")
+
+// CBOR major types (RFC 8949, section 3.1).
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+// MarshalDeltaCBOR encodes a delta as CBOR (RFC 8949), a binary equivalent of
+// its JSON form that is typically much smaller. The encoding is deterministic:
+// map keys are sorted and every number takes its shortest exact form, so the
+// array markers and the 0/2/3 tail codes cost one byte each.
+//
+// The delta may hold the values encoding/json produces, *OrderedMap objects
+// and any Go integer or float type; other values are rejected. Object keys
+// are sorted like those of maps, so key order is not kept.
+func MarshalDeltaCBOR(delta map[string]any) ([]byte, error) {
+	var buf []byte
+	return appendCBOR(buf, delta)
+}
+
+// UnmarshalDeltaCBOR decodes a delta encoded by MarshalDeltaCBOR, or any CBOR
+// map with text keys. Values decode like encoding/json would decode their
+// JSON form: objects to map[string]any, arrays to []any and all numbers to
+// float64, so the result patches exactly like the original delta.
+func UnmarshalDeltaCBOR(data []byte) (map[string]any, error) {
+	d := cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%w: trailing data at offset %d", ErrInvalidCBOR, d.pos)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: delta is not a map", ErrInvalidCBOR)
+	}
+	return m, nil
+}
+
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+}
+
+func appendCBORInt(buf []byte, n int64) []byte {
+	if n < 0 {
+		return appendCBORHead(buf, cborNegInt, uint64(-1-n))
+	}
+	return appendCBORHead(buf, cborUint, uint64(n))
+}
+
+func appendCBORFloat(buf []byte, f float64) []byte {
+	// Integral values, the tail codes among them, are written as integers.
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 && !(f == 0 && math.Signbit(f)) {
+		return appendCBORInt(buf, int64(f))
+	}
+	if h, ok := float16Bits(f); ok {
+		return binary.BigEndian.AppendUint16(append(buf, cborSimple|25), h)
+	}
+	if float64(float32(f)) == f {
+		return binary.BigEndian.AppendUint32(append(buf, cborSimple|26), math.Float32bits(float32(f)))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, cborSimple|27), math.Float64bits(f))
+}
+
+func appendCBOR(buf []byte, v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return append(buf, cborSimple|22), nil
+	case bool:
+		if t {
+			return append(buf, cborSimple|21), nil
+		}
+		return append(buf, cborSimple|20), nil
+	case string:
+		return append(appendCBORHead(buf, cborText, uint64(len(t))), t...), nil
+	case float64:
+		return appendCBORFloat(buf, t), nil
+	case float32:
+		return appendCBORFloat(buf, float64(t)), nil
+	case int:
+		return appendCBORInt(buf, int64(t)), nil
+	case int8:
+		return appendCBORInt(buf, int64(t)), nil
+	case int16:
+		return appendCBORInt(buf, int64(t)), nil
+	case int32:
+		return appendCBORInt(buf, int64(t)), nil
+	case int64:
+		return appendCBORInt(buf, t), nil
+	case uint:
+		return appendCBORHead(buf, cborUint, uint64(t)), nil
+	case uint8:
+		return appendCBORHead(buf, cborUint, uint64(t)), nil
+	case uint16:
+		return appendCBORHead(buf, cborUint, uint64(t)), nil
+	case uint32:
+		return appendCBORHead(buf, cborUint, uint64(t)), nil
+	case uint64:
+		return appendCBORHead(buf, cborUint, t), nil
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return appendCBORInt(buf, n), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("jsondiffgo: cannot encode number %q as CBOR", t.String())
+		}
+		return appendCBORFloat(buf, f), nil
+	case []any:
+		buf = appendCBORHead(buf, cborArray, uint64(len(t)))
+		for _, x := range t {
+			var err error
+			if buf, err = appendCBOR(buf, x); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case *OrderedMap:
+		// Map keys are written in the deterministic order below, not in
+		// document order.
+		if t == nil {
+			return append(buf, cborSimple|22), nil
+		}
+		return appendCBOR(buf, t.values)
+	case map[string]any:
+		// Deterministic order (RFC 8949, section 4.2.1): shorter keys first,
+		// then bytewise.
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		buf = appendCBORHead(buf, cborMap, uint64(len(t)))
+		for _, k := range keys {
+			buf = append(appendCBORHead(buf, cborText, uint64(len(k))), k...)
+			var err error
+			if buf, err = appendCBOR(buf, t[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("jsondiffgo: cannot encode %T as CBOR", v)
+}
+
+// float16Bits returns the IEEE 754 half precision encoding of f if it
+// represents f exactly.
+func float16Bits(f float64) (uint16, bool) {
+	if math.IsNaN(f) {
+		return 0x7e00, true
+	}
+	f32 := float32(f)
+	if float64(f32) != f {
+		return 0, false
+	}
+	bits := math.Float32bits(f32)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127
+	mant := bits & 0x7fffff
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign, true
+	case exp == 128:
+		return sign | 0x7c00, true
+	case exp >= -14 && exp <= 15:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		// Subnormal: the value is m * 2^-24 for an integer m.
+		full := mant | 0x800000
+		shift := uint(-(exp + 1))
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	}
+	return 0, false
+}
+
+func float16Value(h uint16) float64 {
+	exp := int(h >> 10 & 0x1f)
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant != 0 {
+			return math.NaN()
+		}
+		v = math.Inf(1)
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
+
+// cborMaxDepth bounds nesting so that hostile input cannot exhaust the stack.
+const cborMaxDepth = 10000
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidCBOR, fmt.Sprintf(format, args...), d.pos)
+}
+
+// head reads an item head and returns its major type and argument. For
+// indefinite lengths it returns indefinite=true.
+func (d *cborDecoder) head() (major byte, arg uint64, indefinite bool, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, false, d.errorf("unexpected end of input")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b&0xe0, b&0x1f
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == 31 && major >= cborBytes && major != cborTag:
+		return major, 0, true, nil
+	default:
+		return 0, 0, false, d.errorf("reserved additional information %d", info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, false, d.errorf("unexpected end of input")
+	}
+	for _, c := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.pos += size
+	return major, arg, false, nil
+}
+
+// length checks that n items of at least one byte each can still follow.
+func (d *cborDecoder) length(n uint64) (int, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, d.errorf("length %d exceeds input", n)
+	}
+	return int(n), nil
+}
+
+// isBreak consumes the break code ending an indefinite-length item.
+func (d *cborDecoder) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, d.errorf("nesting too deep")
+	}
+	start := d.pos
+	major, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return float64(arg), nil
+	case cborNegInt:
+		return -1 - float64(arg), nil
+	case cborText:
+		return d.text(arg, indefinite)
+	case cborArray:
+		out := []any{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+			if !indefinite && i == 0 {
+				if _, err := d.length(arg); err != nil {
+					return nil, err
+				}
+				out = make([]any, 0, arg)
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case cborMap:
+		if !indefinite {
+			if _, err := d.length(arg); err != nil {
+				return nil, err
+			}
+		}
+		out := map[string]any{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, d.errorf("map key is not a text string")
+			}
+			if _, dup := out[key]; dup {
+				return nil, d.errorf("duplicate map key %q", key)
+			}
+			if out[key], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case cborTag:
+		// Tags carry no meaning for JSON values; decode the tagged item.
+		return d.value(depth + 1)
+	case cborSimple:
+		switch d.data[start] & 0x1f {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			return float16Value(uint16(arg)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case 27:
+			return math.Float64frombits(arg), nil
+		}
+		return nil, d.errorf("unsupported simple value")
+	}
+	return nil, d.errorf("byte strings have no JSON equivalent")
+}
+
+func (d *cborDecoder) text(n uint64, indefinite bool) (string, error) {
+	if !indefinite {
+		l, err := d.length(n)
+		if err != nil {
+			return "", err
+		}
+		s := string(d.data[d.pos : d.pos+l])
+		d.pos += l
+		if !utf8.ValidString(s) {
+			return "", d.errorf("invalid UTF-8 in text string")
+		}
+		return s, nil
+	}
+	var s []byte
+	for !d.isBreak() {
+		major, arg, ind, err := d.head()
+		if err != nil {
+			return "", err
+		}
+		if major != cborText || ind {
+			return "", d.errorf("invalid chunk in text string")
+		}
+		chunk, err := d.text(arg, false)
+		if err != nil {
+			return "", err
+		}
+		s = append(s, chunk...)
+	}
+	return string(s), nil
+}
@@ -0,0 +1,170 @@
+package jsondiffgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestCBOR_Markers(t *testing.T) {
+	delta := map[string]any{
+		"a": []any{1.0, 0.0, 0.0},
+		"list": map[string]any{
+			"_t": "a",
+			"_1": []any{"", 0.0, 3.0},
+			"2":  []any{"x"},
+		},
+		"text": []any{"@@ -1,3 +1,3 @@\n-abc\n+abd\n", 0.0, 2.0},
+		"r":    []any{nil, -1.5},
+		"b":    []any{false, true},
+	}
+	enc, err := MarshalDeltaCBOR(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalDeltaCBOR(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, delta) {
+		t.Fatalf("unexpected round trip. got=%v want=%v", got, delta)
+	}
+	js, _ := json.Marshal(delta)
+	if len(enc) >= len(js) {
+		t.Fatalf("CBOR is not smaller than JSON: %d >= %d bytes", len(enc), len(js))
+	}
+
+	// {"a":[1,0,0]}: map(1) "a" array(3) 1 0 0
+	enc, err = MarshalDeltaCBOR(map[string]any{"a": []any{1.0, 0.0, 0.0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0xa1, 0x61, 'a', 0x83, 0x01, 0x00, 0x00}; !bytes.Equal(enc, want) {
+		t.Fatalf("unexpected encoding. got=% x want=% x", enc, want)
+	}
+}
+
+func TestCBOR_Numbers(t *testing.T) {
+	nums := []any{
+		0.0, 23.0, 24.0, 255.0, 256.0, 65536.0, 4294967296.0, -1.0, -24.0, -25.0, -1e15,
+		1.5, 0.1, -2.75, 65504.0, 5.960464477539063e-08, 1e300, math.Copysign(0, -1),
+		math.MaxFloat64, math.SmallestNonzeroFloat64, float64(1 << 53), float64(1<<53 + 2), math.Inf(-1),
+	}
+	enc, err := MarshalDeltaCBOR(map[string]any{"n": nums})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalDeltaCBOR(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range got["n"].([]any) {
+		f, want := n.(float64), nums[i].(float64)
+		if f != want || math.Signbit(f) != math.Signbit(want) {
+			t.Fatalf("unexpected number at %d. got=%v want=%v", i, f, want)
+		}
+	}
+
+	// Go integers and json.Number decode as float64, like encoding/json.
+	enc, err = MarshalDeltaCBOR(map[string]any{"i": []any{int64(-7), uint8(9), json.Number("2.5")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = UnmarshalDeltaCBOR(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []any{-7.0, 9.0, 2.5}; !reflect.DeepEqual(got["i"], want) {
+		t.Fatalf("unexpected numbers. got=%v want=%v", got["i"], want)
+	}
+}
+
+func TestCBOR_Errors(t *testing.T) {
+	if _, err := MarshalDeltaCBOR(map[string]any{"x": struct{}{}}); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+	for _, in := range [][]byte{
+		{},
+		{0x83, 0x01},                  // truncated array
+		{0x01},                        // not a map
+		{0xa1, 0x01, 0x01},            // integer key
+		{0xa1, 0x61, 'a'},             // missing value
+		{0xa0, 0x00},                  // trailing data
+		{0xa1, 0x61, 'a', 0x41, 0x00}, // byte string
+		{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // huge length
+	} {
+		if _, err := UnmarshalDeltaCBOR(in); !errors.Is(err, ErrInvalidCBOR) {
+			t.Fatalf("expected ErrInvalidCBOR for % x, got %v", in, err)
+		}
+	}
+}
+
+func TestCBOR_IndefiniteLengths(t *testing.T) {
+	// {_ "a": [_ "x", (_ "y", "z")]}
+	in := []byte{0xbf, 0x61, 'a', 0x9f, 0x61, 'x', 0x7f, 0x61, 'y', 0x61, 'z', 0xff, 0xff, 0xff}
+	got, err := UnmarshalDeltaCBOR(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"a": []any{"x", "yz"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected decoding. got=%v want=%v", got, want)
+	}
+}
+
+func TestProperty_CBOR_PatchesIdentically_Quick(t *testing.T) {
+	f := func(x, y jsonObject) bool {
+		d := Diff(x.M, y.M)
+		enc, err := MarshalDeltaCBOR(d)
+		if err != nil {
+			t.Logf("encode: %v", err)
+			return false
+		}
+		dec, err := UnmarshalDeltaCBOR(enc)
+		if err != nil {
+			t.Logf("decode: %v", err)
+			return false
+		}
+		want, err1 := Patch(x.M, d)
+		got, err2 := Patch(x.M, dec)
+		if err1 != nil || err2 != nil {
+			t.Logf("patch: %v, %v", err1, err2)
+			return false
+		}
+		wb, _ := json.Marshal(want)
+		gb, _ := json.Marshal(got)
+		db, _ := json.Marshal(d)
+		eb, _ := json.Marshal(dec)
+		return bytes.Equal(gb, wb) && bytes.Equal(eb, db)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 200}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func FuzzUnmarshalDeltaCBOR(f *testing.F) {
+	seed, _ := MarshalDeltaCBOR(map[string]any{"a": []any{1.0, 0.0, 0.0}, "l": map[string]any{"_t": "a", "_0": []any{"", 1.0, 3.0}}})
+	f.Add(seed)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, err := UnmarshalDeltaCBOR(data)
+		if err != nil {
+			return
+		}
+		enc, err := MarshalDeltaCBOR(d)
+		if err != nil {
+			t.Fatalf("re-encode: %v", err)
+		}
+		again, err := UnmarshalDeltaCBOR(enc)
+		if err != nil {
+			t.Fatalf("decode of re-encoded delta: %v", err)
+		}
+		a, _ := json.Marshal(d)
+		b, _ := json.Marshal(again)
+		if !bytes.Equal(a, b) {
+			t.Fatalf("unstable round trip: %s != %s", a, b)
+		}
+	})
+}
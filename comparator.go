@@ -0,0 +1,135 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// Comparator decides whether two values are equal. It returns ok=false when
+// it does not apply to the given values, in which case the next comparator
+// or the default strict equality is used.
+type Comparator func(a, b any) (equal, ok bool)
+
+type scopedComparator struct {
+	pat    pathPattern
+	scoped bool
+	cmp    Comparator
+}
+
+// EqualFor returns a Comparator that applies fn when both values have
+// dynamic type T.
+func EqualFor[T any](fn func(a, b T) bool) Comparator {
+	return func(a, b any) (bool, bool) {
+		at, ok1 := a.(T)
+		bt, ok2 := b.(T)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		return fn(at, bt), true
+	}
+}
+
+// AbsoluteTolerance treats two floating point numbers as equal when they
+// differ by at most eps.
+func AbsoluteTolerance(eps float64) Comparator {
+	return func(a, b any) (bool, bool) {
+		x, y, ok := floatPair(a, b)
+		if !ok {
+			return false, false
+		}
+		return math.Abs(x-y) <= eps, true
+	}
+}
+
+// RelativeTolerance treats two floating point numbers as equal when their
+// difference is at most rel times the larger magnitude.
+func RelativeTolerance(rel float64) Comparator {
+	return func(a, b any) (bool, bool) {
+		x, y, ok := floatPair(a, b)
+		if !ok {
+			return false, false
+		}
+		if x == y {
+			return true, true
+		}
+		return math.Abs(x-y) <= rel*math.Max(math.Abs(x), math.Abs(y)), true
+	}
+}
+
+// floatPair converts a and b when both are floating point values.
+func floatPair(a, b any) (float64, float64, bool) {
+	x, ok1 := floatValue(a)
+	y, ok2 := floatValue(b)
+	return x, y, ok1 && ok2
+}
+
+func floatValue(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// CaseInsensitive treats strings that are equal under Unicode case folding
+// as equal.
+func CaseInsensitive() Comparator {
+	return EqualFor(strings.EqualFold)
+}
+
+// TimeStrings treats strings that parse to the same instant as equal, so
+// "2024-01-01T00:00:00Z" matches "2024-01-01T01:00:00+01:00". Each string is
+// tried against layouts in order; RFC 3339 is used when none are given.
+// Strings that do not parse are left to the next comparator.
+func TimeStrings(layouts ...string) Comparator {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	parse := func(s string) (time.Time, bool) {
+		for _, l := range layouts {
+			if t, err := time.Parse(l, s); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+	return func(a, b any) (bool, bool) {
+		as, ok1 := a.(string)
+		bs, ok2 := b.(string)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		at, ok1 := parse(as)
+		bt, ok2 := parse(bs)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		return at.Equal(bt), true
+	}
+}
+
+// compare consults the configured comparators for the values at p.
+func (d *differ) compare(p Pointer, a, b any) (equal, ok bool) {
+	for _, c := range d.opts.comparators {
+		if c.scoped && c.pat.match(p) {
+			if eq, ok := c.cmp(a, b); ok {
+				return eq, true
+			}
+		}
+	}
+	for _, c := range d.opts.comparators {
+		if !c.scoped {
+			if eq, ok := c.cmp(a, b); ok {
+				return eq, true
+			}
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,87 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComparator_AbsoluteTolerance(t *testing.T) {
+	a := map[string]any{"t": 20.0, "h": 0.5}
+	b := map[string]any{"t": 20.0 + 1e-12, "h": 0.6}
+	want := map[string]any{"h": []any{0.5, 0.6}}
+	got := Diff(a, b, WithComparator(AbsoluteTolerance(1e-9)))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestComparator_RelativeTolerance(t *testing.T) {
+	a := map[string]any{"big": 1e9, "small": 1.0}
+	b := map[string]any{"big": 1e9 + 1, "small": 1.1}
+	want := map[string]any{"small": []any{1.0, 1.1}}
+	got := Diff(a, b, WithComparator(RelativeTolerance(1e-6)))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestComparator_StringsAndTimes(t *testing.T) {
+	a := parseJSON(t, `{"name":"Alice","at":"2024-01-01T00:00:00Z","note":"x"}`)
+	b := parseJSON(t, `{"name":"ALICE","at":"2024-01-01T01:00:00+01:00","note":"y"}`)
+	want := parseJSON(t, `{"note":["x","y"]}`)
+	// TimeStrings goes first: CaseInsensitive applies to every string pair.
+	got := Diff(a, b, WithComparator(TimeStrings()), WithComparator(CaseInsensitive()))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestComparator_PathScoped(t *testing.T) {
+	a := parseJSON(t, `{"sensors":{"s1":1.0},"price":1.0}`)
+	b := parseJSON(t, `{"sensors":{"s1":1.001},"price":1.001}`)
+	want := parseJSON(t, `{"price":[1.0,1.001]}`)
+	got := Diff(a, b, WithPathComparator("/sensors/*", AbsoluteTolerance(0.01)))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestComparator_EqualForType(t *testing.T) {
+	a := map[string]any{"tags": "a,b", "n": 1.0}
+	b := map[string]any{"tags": "b,a", "n": 1.0}
+	sameSet := EqualFor(func(x, y string) bool {
+		xs, ys := strings.Split(x, ","), strings.Split(y, ",")
+		if len(xs) != len(ys) {
+			return false
+		}
+		seen := map[string]int{}
+		for _, s := range xs {
+			seen[s]++
+		}
+		for _, s := range ys {
+			seen[s]--
+		}
+		for _, n := range seen {
+			if n != 0 {
+				return false
+			}
+		}
+		return true
+	})
+	if got := Diff(a, b, WithComparator(sameSet)); len(got) != 0 {
+		t.Fatalf("expected empty diff, got=%v", got)
+	}
+}
+
+func TestComparator_ArrayAlignment(t *testing.T) {
+	// With tolerance the readings align, so only the new first reading is
+	// reported instead of a full replacement.
+	a := map[string]any{"r": []any{1.0, 2.0, 3.0}}
+	b := map[string]any{"r": []any{0.5, 1.0 + 1e-12, 2.0, 3.0 - 1e-12}}
+	want := map[string]any{"r": map[string]any{"0": []any{0.5}, "_t": "a"}}
+	got := Diff(a, b, WithComparator(AbsoluteTolerance(1e-9)))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package jsondiffgo
+
+import "strconv"
+
+// equal reports whether a and b are equal under the differ's options. It is
+// the single notion of equality used for scalars, for skipping unchanged
+// subtrees and for aligning array items.
+func (d *differ) equal(p Pointer, a, b any) bool {
+	if !d.customEqual {
+		return fastEqual(a, b)
+	}
+	if eq, ok := d.compare(p, a, b); ok {
+		return eq
+	}
+	switch a.(type) {
+	case map[string]any, *OrderedMap:
+		av, _ := objectValues(a)
+		bv, ok := objectValues(b)
+		if !ok {
+			return false
+		}
+		for k, v1 := range av {
+			v2, ok := bv[k]
+			c := d.child(p, k)
+			if d.ignored(c, k, v1, v2) {
+				continue
+			}
+			if !ok || !d.equal(c, v1, v2) {
+				return false
+			}
+		}
+		for k, v2 := range bv {
+			if _, ok := av[k]; !ok && !d.ignored(d.child(p, k), k, nil, v2) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		av := a.([]any)
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		if u, ok := d.unorderedAt(p); ok {
+			return d.equalUnordered(p, u, av, bv)
+		}
+		for i := range av {
+			if !d.equal(d.child(p, strconv.Itoa(i)), av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if d.opts.normalizeNumbers {
+		if eq, ok := numericEqual(a, b); ok {
+			return eq
+		}
+	}
+	return fastEqual(a, b)
+}
+
+// ignored reports whether the object key at p is excluded from the diff.
+func (d *differ) ignored(p Pointer, key string, a, b any) bool {
+	if len(d.opts.includePaths) > 0 && !d.included(p) {
+		return true
+	}
+	if _, ok := d.opts.ignoreKeys[key]; ok {
+		return true
+	}
+	for _, pat := range d.opts.ignorePaths {
+		if pat.match(p) {
+			return true
+		}
+	}
+	for _, fn := range d.opts.ignoreFuncs {
+		if fn(p, a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// included reports whether p is under one of the IncludePaths or on the way
+// to one.
+func (d *differ) included(p Pointer) bool {
+	for _, pat := range d.opts.includePaths {
+		if pat.prefixOf(p) || pat.within(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// arrayItem tags an array element with its index so that location based
+// options can be consulted while Myers aligns the array.
+type arrayItem struct {
+	idx int
+	val any
+}
+
+func indexItems(l []any) []any {
+	out := make([]any, len(l))
+	for i, v := range l {
+		out[i] = arrayItem{idx: i, val: v}
+	}
+	return out
+}
+
+// align computes the Myers edit script for an array, comparing items with
+// equal. Only the lengths of the edits are meaningful to callers when the
+// differ uses a custom equality.
+func (d *differ) align(p Pointer, l1, l2 []any) []MyerDiff {
+	if !d.customEqual {
+		return Myers(l1, l2)
+	}
+	if !d.trackPaths {
+		return myers(l1, l2, func(a, b any) bool { return d.equal(nil, a, b) })
+	}
+	// Items are compared at the index they have in the right-hand array.
+	return myers(indexItems(l1), indexItems(l2), func(a, b any) bool {
+		ai, bi := a.(arrayItem), b.(arrayItem)
+		return d.equal(p.child(strconv.Itoa(bi.idx)), ai.val, bi.val)
+	})
+}
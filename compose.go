@@ -0,0 +1,269 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidDelta is returned when a delta cannot be interpreted, or when two
+// deltas cannot follow one another.
+var ErrInvalidDelta = errors.New("jsondiffgo: invalid delta")
+
+// deltaKind classifies a single delta value.
+type deltaKind int
+
+const (
+	kindNone    deltaKind = iota // no change
+	kindAdd                      // [new]
+	kindReplace                  // [old, new]
+	kindDelete                   // [old, 0, 0]
+	kindObject                   // nested object delta
+	kindArray                    // nested array delta, marked with _t: "a"
+	kindOther                    // moves, text diffs and anything unknown
+)
+
+func deltaKindOf(v any) deltaKind {
+	switch t := v.(type) {
+	case nil:
+		return kindNone
+	case []any:
+		switch {
+		case len(t) == 1:
+			return kindAdd
+		case len(t) == 2:
+			return kindReplace
+		case len(t) == 3 && isZero(t[1]) && isZero(t[2]):
+			return kindDelete
+		}
+	case map[string]any, *OrderedMap:
+		if m, _ := objectValues(t); m["_t"] == "a" {
+			return kindArray
+		}
+		return kindObject
+	}
+	return kindOther
+}
+
+// Compose combines two sequential deltas into one: d1 turns a document x into
+// y and d2 turns y into z, and the result turns x into z directly, so that
+// Patch(x, Compose(d1, d2)) equals Patch(Patch(x, d1), d2).
+//
+// Changes that cancel out disappear from the result: a key added by d1 and
+// deleted by d2 is not mentioned at all, and array items inserted by d1 and
+// removed by d2 leave no trace. Array indices of d2 are remapped to the
+// coordinates of the original document. The result shares no memory with the
+// inputs, and objects the inputs hold as *OrderedMap values, as DiffOrdered
+// returns them, are plain maps in it. An error wrapping ErrInvalidDelta is
+// returned when d2 cannot follow d1, for example when it changes a key d1
+// deleted.
+func Compose(d1, d2 map[string]any) (map[string]any, error) {
+	d1, d2 = plainDelta(d1), plainDelta(d2)
+	if len(d1) == 0 || len(d2) == 0 {
+		if len(d1) == 0 {
+			d1 = d2
+		}
+		if d1 == nil {
+			return map[string]any{}, nil
+		}
+		return deepCopy(d1).(map[string]any), nil
+	}
+	r, err := composeValue(d1, d2)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := deepCopy(r).(map[string]any)
+	if m == nil {
+		m = map[string]any{}
+	}
+	return m, nil
+}
+
+// Reverse returns the delta that undoes delta: Patch(Patch(x, delta),
+// Reverse(delta)) equals x. It relies on the old values the delta records, so
+// it fails with ErrInvalidDelta on text diffs, which do not keep them. Like
+// Compose, it returns *OrderedMap objects of delta as plain maps.
+func Reverse(delta map[string]any) (map[string]any, error) {
+	delta = plainDelta(delta)
+	if len(delta) == 0 {
+		return map[string]any{}, nil
+	}
+	r, err := reverseDelta(delta)
+	if err != nil {
+		return nil, err
+	}
+	return deltaMap(r), nil
+}
+
+// composeValue composes the delta a of a value with the delta b that follows
+// it. A nil result means the value ends up unchanged.
+func composeValue(a, b any) (any, error) {
+	ka, kb := deltaKindOf(a), deltaKindOf(b)
+	if ka == kindOther || kb == kindOther {
+		return nil, fmt.Errorf("%w: cannot compose moves or text diffs", ErrInvalidDelta)
+	}
+	switch {
+	case ka == kindNone:
+		return b, nil
+	case kb == kindNone:
+		return a, nil
+	}
+
+	switch ka {
+	case kindAdd:
+		if kb == kindDelete {
+			return nil, nil
+		}
+		v, err := applyDelta(a.([]any)[0], b)
+		if err != nil {
+			return nil, err
+		}
+		return []any{v}, nil
+	case kindReplace:
+		old := a.([]any)[0]
+		if kb == kindDelete {
+			return []any{old, float64(0), float64(0)}, nil
+		}
+		v, err := applyDelta(a.([]any)[1], b)
+		if err != nil {
+			return nil, err
+		}
+		return replacement(old, v), nil
+	case kindDelete:
+		if kb != kindAdd {
+			return nil, fmt.Errorf("%w: change to a deleted value", ErrInvalidDelta)
+		}
+		return replacement(a.([]any)[0], b.([]any)[0]), nil
+	}
+
+	// a changes the value in place, so b sees the value a produced.
+	switch kb {
+	case kindDelete, kindReplace:
+		old, err := unpatchValue(b.([]any)[0], a)
+		if err != nil {
+			return nil, err
+		}
+		if kb == kindDelete {
+			return []any{old, float64(0), float64(0)}, nil
+		}
+		return replacement(old, b.([]any)[1]), nil
+	case kindAdd:
+		return nil, fmt.Errorf("%w: addition of an existing value", ErrInvalidDelta)
+	}
+	if ka != kb {
+		return nil, fmt.Errorf("%w: object delta combined with array delta", ErrInvalidDelta)
+	}
+	if ka == kindArray {
+		p := newArrayPlan()
+		if err := p.apply(a.(map[string]any)); err != nil {
+			return nil, err
+		}
+		if err := p.apply(b.(map[string]any)); err != nil {
+			return nil, err
+		}
+		if d := p.delta(); d != nil {
+			return d, nil
+		}
+		return nil, nil
+	}
+	return composeObject(a.(map[string]any), b.(map[string]any))
+}
+
+func composeObject(a, b map[string]any) (any, error) {
+	out := make(map[string]any, len(a)+len(b))
+	for k, va := range a {
+		v, err := composeValue(va, b[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		if v != nil {
+			out[k] = v
+		}
+	}
+	for k, vb := range b {
+		if _, seen := a[k]; !seen {
+			out[k] = vb
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// replacement returns the delta replacing old with v, or nil when they are
+// the same.
+func replacement(old, v any) any {
+	if fastEqual(old, v) {
+		return nil
+	}
+	return []any{old, v}
+}
+
+// applyDelta returns the value delta d produces from v, leaving v untouched.
+func applyDelta(v, d any) (any, error) {
+	switch deltaKindOf(d) {
+	case kindNone:
+		return v, nil
+	case kindAdd:
+		return d.([]any)[0], nil
+	case kindReplace:
+		return d.([]any)[1], nil
+	case kindDelete:
+		return nil, nil
+	case kindObject:
+		return doPatch(asMap(v), d.(map[string]any), patchCopy)
+	case kindArray:
+		return applyArrayPatch(asArray(v), d.(map[string]any), patchCopy)
+	}
+	return nil, fmt.Errorf("%w: cannot apply moves or text diffs", ErrInvalidDelta)
+}
+
+// unpatchValue returns the value that delta d turned into v.
+func unpatchValue(v, d any) (any, error) {
+	if d == nil {
+		return v, nil
+	}
+	r, err := reverseDelta(d)
+	if err != nil {
+		return nil, err
+	}
+	return applyDelta(v, r)
+}
+
+// reverseDelta returns the delta that undoes d.
+func reverseDelta(d any) (any, error) {
+	switch deltaKindOf(d) {
+	case kindNone:
+		return nil, nil
+	case kindAdd:
+		return []any{d.([]any)[0], float64(0), float64(0)}, nil
+	case kindReplace:
+		a := d.([]any)
+		return []any{a[1], a[0]}, nil
+	case kindDelete:
+		return []any{d.([]any)[0]}, nil
+	case kindObject:
+		out := map[string]any{}
+		for k, v := range d.(map[string]any) {
+			r, err := reverseDelta(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			if r != nil {
+				out[k] = r
+			}
+		}
+		return out, nil
+	case kindArray:
+		p := newArrayPlan()
+		if err := p.apply(d.(map[string]any)); err != nil {
+			return nil, err
+		}
+		r, err := p.reverse()
+		if err != nil || r == nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w: cannot reverse moves or text diffs", ErrInvalidDelta)
+}
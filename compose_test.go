@@ -0,0 +1,281 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestCompose_Objects(t *testing.T) {
+	x := map[string]any{"a": 1.0, "b": "keep", "c": map[string]any{"d": true}}
+	y := map[string]any{"a": 2.0, "b": "keep", "c": map[string]any{"d": false}, "e": "tmp"}
+	z := map[string]any{"a": 3.0, "b": "keep", "c": map[string]any{"d": false, "f": 1.0}}
+
+	got, err := Compose(Diff(x, y), Diff(y, z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a": []any{1.0, 3.0},
+		"c": map[string]any{"d": []any{true, false}, "f": []any{1.0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+}
+
+func TestCompose_Cancellation(t *testing.T) {
+	x := map[string]any{"a": 1.0, "list": []any{"p", "q"}}
+	y := map[string]any{"a": 2.0, "b": "added", "list": []any{"p", "new", "q"}}
+
+	got, err := Compose(Diff(x, y), Diff(y, x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected changes to cancel out, got %v", got)
+	}
+}
+
+func TestCompose_ArrayIndexRemapping(t *testing.T) {
+	x := map[string]any{"l": []any{"a", "b", "c", "d", "e"}}
+	y := map[string]any{"l": []any{"b", "x", "c", "e"}}
+	z := map[string]any{"l": []any{"y", "b", "x", "e", "z"}}
+
+	c, err := Compose(Diff(x, y), Diff(y, z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Patch(x, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, z) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", got, z)
+	}
+	// The deletions must refer to indices of x.
+	l := c["l"].(map[string]any)
+	for _, key := range []string{"_0", "_2", "_3"} {
+		if _, ok := l[key]; !ok {
+			t.Fatalf("expected deletion %s in %v", key, l)
+		}
+	}
+}
+
+func TestCompose_NestedThenDeleted(t *testing.T) {
+	x := map[string]any{"o": map[string]any{"k": 1.0, "j": "same"}}
+	y := map[string]any{"o": map[string]any{"k": 2.0, "j": "same"}}
+	z := map[string]any{}
+
+	got, err := Compose(Diff(x, y), Diff(y, z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The deletion records the value as it was in x.
+	want := map[string]any{"o": []any{map[string]any{"k": 1.0, "j": "same"}, float64(0), float64(0)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+}
+
+func TestCompose_Moves(t *testing.T) {
+	x := []any{"a", "a", "b", "c"}
+	d1 := map[string]any{"_t": "a", "_3": []any{"", 0.0, 3.0}}
+	d2 := map[string]any{"_t": "a", "_2": []any{"", 0.0, 3.0}, "1": []any{"n"}}
+
+	y, err := Patch(map[string]any{"r": x}, map[string]any{"r": d1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Patch(y, map[string]any{"r": d2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Compose(d1, d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Patch(map[string]any{"r": x}, map[string]any{"r": c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", got, want)
+	}
+}
+
+func TestCompose_Invalid(t *testing.T) {
+	d1 := map[string]any{"a": []any{1.0, 0.0, 0.0}}
+	d2 := map[string]any{"a": []any{1.0, 2.0}}
+	if _, err := Compose(d1, d2); !errors.Is(err, ErrInvalidDelta) {
+		t.Fatalf("expected ErrInvalidDelta, got %v", err)
+	}
+}
+
+func TestCompose_DoesNotShareMemory(t *testing.T) {
+	d1 := map[string]any{"a": []any{[]any{1.0}}}
+	got, err := Compose(d1, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scribble(got)
+	if !reflect.DeepEqual(d1, map[string]any{"a": []any{[]any{1.0}}}) {
+		t.Fatalf("result aliases its input: %v", d1)
+	}
+}
+
+// mutate returns a copy of v with a few random edits, so that successive
+// versions of a document share most of their structure.
+func mutate(r *rand.Rand, v any, depth int) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			switch r.Intn(8) {
+			case 0:
+				continue
+			case 1:
+				out[k] = genValue(r, depth+1, 4)
+			default:
+				out[k] = mutate(r, x, depth+1)
+			}
+		}
+		if r.Intn(3) == 0 {
+			out[randKey(r)] = genValue(r, depth+1, 4)
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(t)+1)
+		for _, x := range t {
+			switch r.Intn(6) {
+			case 0:
+				continue
+			case 1:
+				out = append(out, genValue(r, depth+1, 4))
+			default:
+				out = append(out, mutate(r, x, depth+1))
+			}
+			if r.Intn(6) == 0 {
+				out = append(out, genValue(r, depth+1, 4))
+			}
+		}
+		return out
+	}
+	if r.Intn(4) == 0 {
+		return genValue(r, depth+1, 4)
+	}
+	return v
+}
+
+type jsonHistory struct{ V [3]map[string]any }
+
+// Generate implements quick.Generator with three successive versions of a
+// random document.
+func (jsonHistory) Generate(r *rand.Rand, size int) reflect.Value {
+	var h jsonHistory
+	h.V[0] = jsonObject{}.Generate(r, size).Interface().(jsonObject).M
+	h.V[1] = mutate(r, h.V[0], 0).(map[string]any)
+	h.V[2] = mutate(r, h.V[1], 0).(map[string]any)
+	return reflect.ValueOf(h)
+}
+
+func TestProperty_Compose_Quick(t *testing.T) {
+	cfg := &quick.Config{
+		MaxCount: 300,
+		Rand:     newPseudoCryptoRand(),
+	}
+	check := func(x, y, z map[string]any) bool {
+		d1, d2 := Diff(x, y), Diff(y, z)
+		y1, err := Patch(x, d1)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		want, err := Patch(y1, d2)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		c, err := Compose(d1, d2)
+		if err != nil {
+			t.Logf("Compose failed: %v", err)
+			return false
+		}
+		p, err := Patch(x, c)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(p, want) || !reflect.DeepEqual(want, z) {
+			bx, _ := json.Marshal(x)
+			bz, _ := json.Marshal(z)
+			b1, _ := json.Marshal(d1)
+			b2, _ := json.Marshal(d2)
+			bc, _ := json.Marshal(c)
+			bp, _ := json.Marshal(p)
+			t.Logf("x=%s\nz=%s\nd1=%s\nd2=%s\ncomposed=%s\npatched=%s", bx, bz, b1, b2, bc, bp)
+			return false
+		}
+		return true
+	}
+	related := func(h jsonHistory) bool { return check(h.V[0], h.V[1], h.V[2]) }
+	if err := quick.Check(related, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+	unrelated := func(o1, o2, o3 jsonObject) bool { return check(o1.M, o2.M, o3.M) }
+	if err := quick.Check(unrelated, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
+
+func TestReverse_Arrays(t *testing.T) {
+	x := map[string]any{"l": []any{"a", map[string]any{"k": 1.0}, "c", "d"}}
+	y := map[string]any{"l": []any{"n", "a", map[string]any{"k": 2.0}, "d", "m"}}
+
+	r, err := Reverse(Diff(x, y))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Patch(y, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, x) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", got, x)
+	}
+}
+
+func TestProperty_Reverse_Quick(t *testing.T) {
+	cfg := &quick.Config{
+		MaxCount: 300,
+		Rand:     newPseudoCryptoRand(),
+	}
+	prop := func(h jsonHistory) bool {
+		x, y := h.V[0], h.V[1]
+		d := Diff(x, y)
+		r, err := Reverse(d)
+		if err != nil {
+			t.Logf("Reverse failed: %v", err)
+			return false
+		}
+		p, err := Patch(y, r)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(p, x) {
+			bx, _ := json.Marshal(x)
+			bd, _ := json.Marshal(d)
+			br, _ := json.Marshal(r)
+			t.Logf("x=%s\ndelta=%s\nreversed=%s", bx, bd, br)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+// Package history keeps versioned JSON documents as a chain of jsondiffgo
+// deltas, with a full snapshot stored every few revisions so that any revision
+// can be rebuilt quickly.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jsondiffgo"
+)
+
+// ErrNoRevision is returned for revision numbers the store does not hold.
+var ErrNoRevision = errors.New("history: no such revision")
+
+// Store records successive versions of a JSON object. Revisions are numbered
+// from 1; each one stores the delta from its predecessor (revision 1 from the
+// empty object) in the jsondiffpatch format.
+//
+// Documents are stored as JSON, so numbers come back as float64 and values
+// that do not survive encoding/json are rejected by Commit.
+type Store interface {
+	// Commit records doc as a new revision and returns its number.
+	Commit(doc map[string]any) (int, error)
+	// Head returns the latest revision, or 0 for an empty store.
+	Head() int
+	// Get rebuilds the document as of revision rev.
+	Get(rev int) (map[string]any, error)
+	// Log lists all revisions, oldest first.
+	Log() ([]Entry, error)
+	// DiffRevisions returns the delta from revision r1 to revision r2.
+	DiffRevisions(r1, r2 int) (map[string]any, error)
+	// Revert commits the content of revision rev as a new revision and
+	// returns its number.
+	Revert(rev int) (int, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Entry describes one revision in a Store's log.
+type Entry struct {
+	Rev      int
+	Time     time.Time
+	Delta    map[string]any // changes from the previous revision
+	Snapshot bool           // whether the full document is stored too
+}
+
+// Option configures a Store.
+type Option func(*config)
+
+type config struct {
+	snapshotEvery int
+	now           func() time.Time
+}
+
+// SnapshotEvery stores a full snapshot every n revisions, starting with
+// revision 1. Smaller values make Get faster and the store larger; n <= 0
+// keeps only the first snapshot. The default is 16.
+func SnapshotEvery(n int) Option {
+	return func(c *config) { c.snapshotEvery = n }
+}
+
+func newConfig(opts []Option) config {
+	c := config{snapshotEvery: 16, now: time.Now}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// record is one revision as it is kept in memory and written to disk. The
+// snapshot is an interface so that an empty document is still stored.
+type record struct {
+	Rev      int            `json:"rev"`
+	Time     time.Time      `json:"time"`
+	Delta    map[string]any `json:"delta"`
+	Snapshot any            `json:"snapshot,omitempty"`
+}
+
+// revisions implements Store on a slice of records; persist, when set, is
+// called with every new record before it becomes visible.
+type revisions struct {
+	cfg     config
+	persist func(record) error
+
+	mu      sync.RWMutex
+	records []record
+	head    map[string]any
+}
+
+func (s *revisions) Commit(doc map[string]any) (int, error) {
+	doc, err := normalize(doc)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(doc)
+}
+
+func (s *revisions) commit(doc map[string]any) (int, error) {
+	prev := s.head
+	if prev == nil {
+		prev = map[string]any{}
+	}
+	rec := record{
+		Rev:   len(s.records) + 1,
+		Time:  s.cfg.now(),
+		Delta: jsondiffgo.Diff(prev, doc),
+	}
+	if s.snapshotDue(rec.Rev) {
+		rec.Snapshot = doc
+	}
+	if s.persist != nil {
+		if err := s.persist(rec); err != nil {
+			return 0, err
+		}
+	}
+	s.records = append(s.records, rec)
+	s.head = doc
+	return rec.Rev, nil
+}
+
+func (s *revisions) snapshotDue(rev int) bool {
+	if rev == 1 {
+		return true
+	}
+	return s.cfg.snapshotEvery > 0 && (rev-1)%s.cfg.snapshotEvery == 0
+}
+
+func (s *revisions) Head() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+func (s *revisions) Get(rev int) (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.get(rev)
+}
+
+// get rebuilds rev from the nearest snapshot, patching forward from an
+// earlier one or backward, with reversed deltas, from a later one.
+func (s *revisions) get(rev int) (map[string]any, error) {
+	if rev < 1 || rev > len(s.records) {
+		return nil, fmt.Errorf("%w: %d", ErrNoRevision, rev)
+	}
+	if rev == len(s.records) {
+		return copyObject(s.head), nil
+	}
+	before := rev
+	for s.records[before-1].Snapshot == nil {
+		before--
+	}
+	after := rev
+	for after < len(s.records) && s.records[after-1].Snapshot == nil {
+		after++
+	}
+	if s.records[after-1].Snapshot != nil && after-rev < rev-before {
+		return s.rewind(after, rev)
+	}
+	return s.replay(before, rev)
+}
+
+// replay patches the snapshot of revision from forward to revision to.
+// Patch returns copies, so only an unpatched snapshot needs copying.
+func (s *revisions) replay(from, to int) (map[string]any, error) {
+	doc := s.records[from-1].Snapshot.(map[string]any)
+	if from == to {
+		return copyObject(doc), nil
+	}
+	for r := from + 1; r <= to; r++ {
+		var err error
+		if doc, err = jsondiffgo.Patch(doc, s.records[r-1].Delta); err != nil {
+			return nil, fmt.Errorf("history: revision %d: %w", r, err)
+		}
+	}
+	return doc, nil
+}
+
+// rewind undoes the deltas from the snapshot of revision from back to
+// revision to.
+func (s *revisions) rewind(from, to int) (map[string]any, error) {
+	doc := s.records[from-1].Snapshot.(map[string]any)
+	for r := from; r > to; r-- {
+		undo, err := jsondiffgo.Reverse(s.records[r-1].Delta)
+		if err == nil {
+			doc, err = jsondiffgo.Patch(doc, undo)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("history: revision %d: %w", r, err)
+		}
+	}
+	return doc, nil
+}
+
+func (s *revisions) Log() ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	log := make([]Entry, len(s.records))
+	for i, rec := range s.records {
+		log[i] = Entry{Rev: rec.Rev, Time: rec.Time, Delta: copyObject(rec.Delta), Snapshot: rec.Snapshot != nil}
+	}
+	return log, nil
+}
+
+func (s *revisions) DiffRevisions(r1, r2 int) (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, err := s.get(r1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.get(r2)
+	if err != nil {
+		return nil, err
+	}
+	return jsondiffgo.Diff(a, b), nil
+}
+
+func (s *revisions) Revert(rev int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := s.get(rev)
+	if err != nil {
+		return 0, err
+	}
+	return s.commit(doc)
+}
+
+// load appends records read back from storage, checking that they continue
+// the chain, and rebuilds the head document.
+func (s *revisions) load(recs []record) error {
+	for _, rec := range recs {
+		if rec.Rev != len(s.records)+1 {
+			return fmt.Errorf("history: revision %d found where %d was expected", rec.Rev, len(s.records)+1)
+		}
+		if rec.Snapshot != nil {
+			if _, ok := rec.Snapshot.(map[string]any); !ok {
+				return fmt.Errorf("history: revision %d: snapshot is not an object", rec.Rev)
+			}
+		} else if rec.Rev == 1 {
+			return errors.New("history: revision 1 has no snapshot")
+		}
+		s.records = append(s.records, rec)
+	}
+	if len(s.records) == 0 {
+		return nil
+	}
+	// get starts from the head, so rebuild it from the last snapshot.
+	last := len(s.records)
+	before := last
+	for s.records[before-1].Snapshot == nil {
+		before--
+	}
+	head, err := s.replay(before, last)
+	if err != nil {
+		return err
+	}
+	s.head = head
+	return nil
+}
+
+// normalize returns a copy of doc as encoding/json would decode it.
+func normalize(doc map[string]any) (map[string]any, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out, nil
+}
+
+// copyObject deep-copies a decoded JSON object, document or delta: Patch
+// shares no memory with its inputs, so an empty delta makes a copy.
+func copyObject(m map[string]any) map[string]any {
+	out, _ := jsondiffgo.Patch(m, nil)
+	return out
+}
@@ -0,0 +1,246 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// versions returns successive revisions of a small document.
+func versions(n int) []map[string]any {
+	out := make([]map[string]any, n)
+	items := []any{}
+	for i := range out {
+		items = append(items, float64(i))
+		if i%3 == 2 {
+			items = items[1:]
+		}
+		out[i] = map[string]any{
+			"rev":   float64(i + 1),
+			"items": append([]any(nil), items...),
+			"meta":  map[string]any{"even": i%2 == 0},
+		}
+		if i%4 == 0 {
+			out[i]["marker"] = "set"
+		}
+	}
+	return out
+}
+
+func stores(t *testing.T, opts ...Option) map[string]Store {
+	t.Helper()
+	fs, err := OpenFileStore(filepath.Join(t.TempDir(), "doc.jsonl"), opts...)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	t.Cleanup(func() { fs.Close() })
+	return map[string]Store{"memory": NewMemoryStore(opts...), "file": fs}
+}
+
+func TestStore_GetEveryRevision(t *testing.T) {
+	docs := versions(20)
+	for name, s := range stores(t, SnapshotEvery(5)) {
+		for i, doc := range docs {
+			rev, err := s.Commit(doc)
+			if err != nil || rev != i+1 {
+				t.Fatalf("%s: Commit = %d, %v", name, rev, err)
+			}
+		}
+		if s.Head() != len(docs) {
+			t.Fatalf("%s: Head = %d", name, s.Head())
+		}
+		for i, want := range docs {
+			got, err := s.Get(i + 1)
+			if err != nil {
+				t.Fatalf("%s: Get(%d): %v", name, i+1, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s: unexpected revision %d. got=%v want=%v", name, i+1, got, want)
+			}
+		}
+		if _, err := s.Get(0); !errors.Is(err, ErrNoRevision) {
+			t.Fatalf("%s: expected ErrNoRevision, got %v", name, err)
+		}
+	}
+}
+
+func TestStore_LogDiffAndRevert(t *testing.T) {
+	docs := versions(6)
+	for name, s := range stores(t, SnapshotEvery(2)) {
+		for _, doc := range docs {
+			if _, err := s.Commit(doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		log, err := s.Log()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var snapshots []int
+		for _, e := range log {
+			if e.Snapshot {
+				snapshots = append(snapshots, e.Rev)
+			}
+		}
+		if len(log) != 6 || !reflect.DeepEqual(snapshots, []int{1, 3, 5}) {
+			t.Fatalf("%s: unexpected log %+v", name, log)
+		}
+		if !reflect.DeepEqual(log[1].Delta["rev"], []any{1.0, 2.0}) {
+			t.Fatalf("%s: unexpected delta %v", name, log[1].Delta)
+		}
+
+		d, err := s.DiffRevisions(5, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(d["rev"], []any{5.0, 2.0}) {
+			t.Fatalf("%s: unexpected delta %v", name, d)
+		}
+
+		rev, err := s.Revert(2)
+		if err != nil || rev != 7 {
+			t.Fatalf("%s: Revert = %d, %v", name, rev, err)
+		}
+		got, err := s.Get(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, docs[1]) {
+			t.Fatalf("%s: unexpected reverted revision. got=%v want=%v", name, got, docs[1])
+		}
+	}
+}
+
+func TestStore_ResultsAreCopies(t *testing.T) {
+	s := NewMemoryStore()
+	doc := map[string]any{"l": []any{1.0}}
+	if _, err := s.Commit(doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["l"].([]any)[0] = 2.0
+	got, _ := s.Get(1)
+	got["l"] = "changed"
+	again, _ := s.Get(1)
+	if !reflect.DeepEqual(again, map[string]any{"l": []any{1.0}}) {
+		t.Fatalf("store shares memory with callers: %v", again)
+	}
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.jsonl")
+	docs := versions(9)
+	s, err := OpenFileStore(path, SnapshotEvery(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs[:7] {
+		if _, err := s.Commit(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFileStore(path, SnapshotEvery(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, doc := range docs[7:] {
+		if _, err := s.Commit(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, want := range docs {
+		got, err := s.Get(i + 1)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected revision %d after reopening: %v, %v", i+1, got, err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 9 || !strings.Contains(lines[1], `"delta":{`) {
+		t.Fatalf("unexpected file contents:\n%s", b)
+	}
+}
+
+func TestFileStore_RejectsBrokenChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.jsonl")
+	data := `{"rev":1,"time":"2024-01-01T00:00:00Z","delta":{"a":[1]},"snapshot":{"a":1}}
+{"rev":3,"time":"2024-01-01T00:00:00Z","delta":{"a":[1,2]}}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStore(path); err == nil {
+		t.Fatal("expected an error for a missing revision")
+	}
+}
+
+func TestFileStore_DropsIncompleteLastRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.jsonl")
+	docs := versions(3)
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs[:2] {
+		if _, err := s.Commit(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+	// A crash in the middle of writing revision 3.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"rev":3,"time":"2024-01-01T00:`)
+	f.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	if s.Head() != 2 {
+		t.Fatalf("unexpected head %d", s.Head())
+	}
+	if _, err := s.Commit(docs[2]); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("reopening after the recovery: %v", err)
+	}
+	defer s.Close()
+	for i, want := range docs {
+		got, err := s.Get(i + 1)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected revision %d: %v, %v", i+1, got, err)
+		}
+	}
+}
+
+func TestFileStore_RejectsCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.jsonl")
+	data := `{"rev":1,"time":"2024-01-01T00:00:00Z","delta":{"a":[1]},"snapshot":{"a":1}}
+{"rev":2,"time":"2024-01-01T00:
+{"rev":3,"time":"2024-01-01T00:00:00Z","delta":{"a":[1,2]}}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStore(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error for line 2, got %v", err)
+	}
+}
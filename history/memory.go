@@ -0,0 +1,17 @@
+package history
+
+// MemoryStore is a Store that keeps all revisions in memory. It is safe for
+// concurrent use.
+type MemoryStore struct {
+	revisions
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	return &MemoryStore{revisions{cfg: newConfig(opts)}}
+}
+
+// Close is a no-op.
+func (s *MemoryStore) Close() error { return nil }
@@ -0,0 +1,144 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreKeys(t *testing.T) {
+	a := parseJSON(t, `{"name":"a","updatedAt":"1","meta":{"etag":"x","v":1},"gone":{"etag":"y"}}`)
+	b := parseJSON(t, `{"name":"b","updatedAt":"2","meta":{"etag":"z","v":1},"etag":"new"}`)
+	want := parseJSON(t, `{"name":["a","b"],"gone":[{"etag":"y"},0,0]}`)
+	got := Diff(a, b, IgnoreKeys("updatedAt", "etag"))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestIgnorePaths_Glob(t *testing.T) {
+	a := parseJSON(t, `{"items":[{"id":1,"meta":{"t":1}},{"id":2,"meta":{"t":1}}],"meta":{"t":1}}`)
+	b := parseJSON(t, `{"items":[{"id":1,"meta":{"t":2}},{"id":3,"meta":{"t":2}}],"meta":{"t":2}}`)
+	want := parseJSON(t, `{"items":{"1":{"id":[2,3]},"_t":"a"},"meta":{"t":[1,2]}}`)
+	got := Diff(a, b, IgnorePaths("/items/*/meta"))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestIgnoreFunc(t *testing.T) {
+	a := parseJSON(t, `{"_links":{"self":"/a"},"x":{"_links":1,"y":1}}`)
+	b := parseJSON(t, `{"_links":{"self":"/b"},"x":{"_links":2,"y":2}}`)
+	var seen []string
+	pred := func(p Pointer, _, _ any) bool {
+		seen = append(seen, p.String())
+		return p[len(p)-1] == "_links"
+	}
+	want := parseJSON(t, `{"x":{"y":[1,2]}}`)
+	got := Diff(a, b, IgnoreFunc(pred))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+	if !strings.Contains(strings.Join(seen, " "), "/x/_links") {
+		t.Fatalf("predicate did not see nested path, saw %v", seen)
+	}
+}
+
+func TestIgnore_ArrayItemsStillMatch(t *testing.T) {
+	// Items that only differ in ignored fields must be aligned as equal, so
+	// the only change is the inserted first item.
+	a := parseJSON(t, `{"l":[{"id":1,"etag":"a"},{"id":2,"etag":"b"}]}`)
+	b := parseJSON(t, `{"l":[{"id":0},{"id":1,"etag":"c"},{"id":2,"etag":"d"}]}`)
+	want := parseJSON(t, `{"l":{"0":[{"id":0}],"_t":"a"}}`)
+	for _, opt := range []Option{IgnoreKeys("etag"), IgnorePaths("/l/*/etag")} {
+		got := Diff(a, b, opt)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+		}
+	}
+}
+
+func TestIncludePaths(t *testing.T) {
+	a := parseJSON(t, `{"metadata":{"labels":{"app":"a"},"annotations":{"rev":"1"}},"spec":{"replicas":1},"status":{"ready":0}}`)
+	b := parseJSON(t, `{"metadata":{"labels":{"app":"b"},"annotations":{"rev":"2"}},"spec":{"replicas":2},"status":{"ready":2},"extra":true}`)
+	want := parseJSON(t, `{"metadata":{"labels":{"app":["a","b"]}},"spec":{"replicas":[1,2]}}`)
+	var seen []string
+	visit := func(p Pointer, _, _ any) bool {
+		seen = append(seen, p.String())
+		return false
+	}
+	got := Diff(a, b, IncludePaths("/spec", "/metadata/labels"), IgnoreFunc(visit))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+	for _, p := range seen {
+		if strings.HasPrefix(p, "/status") || strings.HasPrefix(p, "/metadata/annotations") || p == "/extra" {
+			t.Fatalf("excluded subtree was traversed: %v", seen)
+		}
+	}
+
+	patched, err := Patch(a.(map[string]any), got)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if patched["status"].(map[string]any)["ready"] != 0.0 {
+		t.Fatalf("unexpected patch result: %v", patched)
+	}
+}
+
+func TestIncludePaths_ArrayItems(t *testing.T) {
+	a := parseJSON(t, `{"items":[{"name":"a","price":1},{"name":"b","price":2}]}`)
+	b := parseJSON(t, `{"items":[{"name":"a","price":5},{"name":"c","price":2}]}`)
+	want := parseJSON(t, `{"items":{"1":{"name":["b","c"]},"_t":"a"}}`)
+	got := Diff(a, b, IncludePaths("/items/*/name"))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+	if got := Diff(a, b, IncludePaths("/other")); len(got) != 0 {
+		t.Fatalf("unexpected diff. got=%v", got)
+	}
+}
+
+func TestIncludePaths_ArrayIndices(t *testing.T) {
+	for _, tc := range []struct{ a, b, include, want string }{
+		// Inserts outside the included index are dropped.
+		{`{"l":[1,2]}`, `{"l":[5,1,2]}`, "/l/1", `{}`},
+		{`{"l":[1,2]}`, `{"l":[5,1,2]}`, "/l/0", `{"l":{"0":[5],"_t":"a"}}`},
+		// Removed items are matched by their left-hand index, the others by
+		// their right-hand index.
+		{`{"l":[{"v":1},{"v":2}]}`, `{"l":[{"v":0},{"v":1},{"v":3}]}`, "/l/1", `{"l":{"_1":[{"v":2},0,0],"_t":"a"}}`},
+		{`{"l":[{"v":1},{"v":2}]}`, `{"l":[{"v":0},{"v":1},{"v":3}]}`, "/l/2", `{"l":{"2":[{"v":3}],"_t":"a"}}`},
+		{`{"l":[{"v":1},{"v":2}]}`, `{"l":[{"v":1},{"v":3}]}`, "/l/1", `{"l":{"1":{"v":[2,3]},"_t":"a"}}`},
+		{`{"l":[{"v":1},{"v":2}]}`, `{"l":[{"v":1},{"v":3}]}`, "/l/0", `{}`},
+		{`{"l":[1,2]}`, `{"l":[9]}`, "/l/0", `{"l":{"0":[9],"_0":[1,0,0],"_t":"a"}}`},
+		// Inserted items are reduced to their included paths.
+		{`{"l":[{"n":"a","p":1}]}`, `{"l":[{"n":"z","p":9},{"n":"a","p":5}]}`, "/l/*/n", `{"l":{"0":[{"n":"z"}],"_t":"a"}}`},
+		{`{"l":[{"x":[1,2]}]}`, `{"l":[{"x":[0,1,2]}]}`, "/l/0/x/1", `{}`},
+		{`{"l":[{"x":[1,2]}]}`, `{"l":[{"x":[0,1,2]}]}`, "/l/0/x/0", `{"l":{"0":{"x":{"0":[0],"_t":"a"}},"_t":"a"}}`},
+	} {
+		a, b := parseJSON(t, tc.a), parseJSON(t, tc.b)
+		got := Diff(a, b, IncludePaths(tc.include))
+		if !reflect.DeepEqual(got, parseJSON(t, tc.want)) {
+			t.Fatalf("unexpected diff for %s with %s. got=%v want=%s", tc.a, tc.include, got, tc.want)
+		}
+		if _, err := Patch(a.(map[string]any), got); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	p, err := ParsePointer("/a~1b/~0c/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Pointer{"a/b", "~c", "0"}); !reflect.DeepEqual(p, want) {
+		t.Fatalf("got=%q want=%q", p, want)
+	}
+	if s := p.String(); s != "/a~1b/~0c/0" {
+		t.Fatalf("round trip mismatch: %s", s)
+	}
+	if _, err := ParsePointer("a"); err == nil {
+		t.Fatal("expected error for pointer without leading slash")
+	}
+}
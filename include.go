@@ -0,0 +1,155 @@
+package jsondiffgo
+
+import (
+	"slices"
+	"strconv"
+)
+
+// restrictsItems reports whether IncludePaths select only some of the items
+// of the array at p, or only some of their content.
+func (d *differ) restrictsItems(p Pointer) bool {
+	if len(d.opts.includePaths) == 0 {
+		return false
+	}
+	for _, pat := range d.opts.includePaths {
+		if pat.prefixOf(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffIncludedItems diffs an array on the way to an included path. The items
+// are aligned comparing every item by the included paths below any index;
+// the right-hand array is then rebuilt from l1 with only the included
+// changes, and the delta is the one from l1 to that array.
+//
+// Items are addressed by their index in l2, and removed items by their index
+// in l1. An inserted or kept item whose index is included takes its
+// right-hand value, reduced to the included paths below it; the others keep
+// their left-hand value and their order among the items of l1.
+func (d *differ) diffIncludedItems(p Pointer, l1, l2 []any) any {
+	full, _ := d.anyIndex(p).diffList(p, l1, l2).(map[string]any)
+	if full == nil {
+		return nil
+	}
+	plan := newArrayPlan()
+	plan.ensure(len(l1))
+	if err := plan.apply(full); err != nil {
+		return full
+	}
+
+	type item struct {
+		old int // index in l1, or -1 for an inserted value
+		pos int // index in the unrestricted result, or -1 for a removed item
+		val any
+	}
+	var out, kept []item
+	for j, s := range plan.items {
+		c := p.child(strconv.Itoa(j))
+		switch {
+		case s.old < 0:
+			if v, ok := d.includedValue(c, nil, l2[j], true); ok {
+				out = append(out, item{old: -1, pos: j, val: v})
+			}
+		case d.included(c):
+			v, _ := d.includedValue(c, l1[s.old], l2[j], false)
+			out = append(out, item{old: s.old, pos: j, val: v})
+		default:
+			kept = append(kept, item{old: s.old, pos: j, val: l1[s.old]})
+		}
+	}
+	for i := range plan.deleted {
+		if !d.included(p.child(strconv.Itoa(i))) {
+			kept = append(kept, item{old: i, pos: -1, val: l1[i]})
+		}
+	}
+
+	// Items whose changes are excluded keep their order among the items of
+	// l1: each goes after the nearest preceding one, else before the first
+	// later one, else where it would be without the restriction.
+	slices.SortFunc(kept, func(a, b item) int { return a.old - b.old })
+	for _, k := range kept {
+		at, prev := -1, -1
+		for i, o := range out {
+			if o.old >= 0 && o.old < k.old && o.old > prev {
+				at, prev = i+1, o.old
+			}
+		}
+		if at < 0 {
+			at = slices.IndexFunc(out, func(o item) bool { return o.old >= 0 })
+		}
+		if at < 0 {
+			at = slices.IndexFunc(out, func(o item) bool { return k.pos >= 0 && o.pos > k.pos })
+		}
+		if at < 0 {
+			at = len(out)
+		}
+		out = slices.Insert(out, at, k)
+	}
+
+	target := make([]any, len(out))
+	for i, o := range out {
+		target[i] = o.val
+	}
+	return d.unfiltered().diffList(p, l1, target)
+}
+
+// includedValue returns the value at c with only the included changes from
+// a to b applied, and false when none of b is included. For an inserted
+// value, a is ignored and the changes are applied to an empty container.
+func (d *differ) includedValue(c Pointer, a, b any, inserted bool) (any, bool) {
+	for _, pat := range d.opts.includePaths {
+		if pat.prefixOf(c) {
+			return b, true
+		}
+	}
+	if !d.included(c) {
+		return a, false
+	}
+	if inserted {
+		if _, ok := b.([]any); ok {
+			a = []any{}
+		} else if isObject(b) {
+			a = map[string]any{}
+		} else {
+			return nil, false
+		}
+	}
+	delta := d.diff(c, a, b)
+	if delta == nil {
+		return a, true
+	}
+	v, err := applyDelta(a, delta)
+	if err != nil {
+		return a, true
+	}
+	return v, true
+}
+
+// anyIndex returns a differ whose include patterns match every index of the
+// array at p, to align its items by content alone.
+func (d *differ) anyIndex(p Pointer) *differ {
+	c := *d
+	c.opts.includePaths = make([]pathPattern, len(d.opts.includePaths))
+	for i, pat := range d.opts.includePaths {
+		if pat.within(p) {
+			pat = slices.Clone(pat)
+			pat[len(p)] = "*"
+		}
+		c.opts.includePaths[i] = pat
+	}
+	return &c
+}
+
+// unfiltered returns a differ without the options that exclude values,
+// for diffing against a value they have already been applied to.
+func (d *differ) unfiltered() *differ {
+	c := *d
+	c.opts.includePaths = nil
+	c.opts.ignorePaths = nil
+	c.opts.ignoreKeys = nil
+	c.opts.ignoreFuncs = nil
+	c.setFlags()
+	return &c
+}
@@ -0,0 +1,109 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+func TestPatchInPlace_RoundTrip_Quick(t *testing.T) {
+	cfg := &quick.Config{MaxCount: 200, Rand: newPseudoCryptoRand()}
+	prop := func(o1, o2 jsonObject) bool {
+		d := Diff(o1.M, o2.M)
+		doc := deepCopy(o1.M).(map[string]any)
+		if err := PatchInPlace(doc, d); err != nil {
+			t.Logf("PatchInPlace failed: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(doc, o2.M) {
+			b1, _ := json.Marshal(o1.M)
+			b2, _ := json.Marshal(o2.M)
+			dp, _ := json.Marshal(d)
+			t.Logf("o1=%s\no2=%s\ndiff=%s", b1, b2, dp)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
+
+func TestPatchInPlace_ReusesContainers(t *testing.T) {
+	inner := map[string]any{"x": float64(1), "y": float64(2)}
+	list := []any{float64(1), float64(2), float64(3), float64(4)}
+	doc := map[string]any{"inner": inner, "list": list}
+	diff := map[string]any{
+		"inner": map[string]any{"x": []any{float64(1), float64(5)}},
+		"list":  map[string]any{"_0": []any{float64(1), 0, 0}, "_t": "a"},
+	}
+	if err := PatchInPlace(doc, diff); err != nil {
+		t.Fatalf("PatchInPlace failed: %v", err)
+	}
+	if inner["x"] != float64(5) {
+		t.Fatalf("nested object was not updated in place: %v", inner)
+	}
+	got := doc["list"].([]any)
+	if !reflect.DeepEqual(got, []any{float64(2), float64(3), float64(4)}) {
+		t.Fatalf("unexpected list: %v", got)
+	}
+	if &got[0] != &list[0] {
+		t.Fatal("deletion reallocated the array")
+	}
+}
+
+func TestPatchInPlace_Moves(t *testing.T) {
+	doc := map[string]any{"1": []any{float64(1), float64(2), float64(3)}}
+	diff := map[string]any{"1": map[string]any{"_0": []any{"", float64(2), float64(3)}, "_2": []any{"", float64(0), float64(3)}, "_t": "a"}}
+	if err := PatchInPlace(doc, diff); err != nil {
+		t.Fatalf("PatchInPlace failed: %v", err)
+	}
+	want := map[string]any{"1": []any{float64(3), float64(2), float64(1)}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("patch reorder mismatch: got=%v want=%v", doc, want)
+	}
+}
+
+func TestPatchInPlace_NilDocument(t *testing.T) {
+	if err := PatchInPlace(nil, map[string]any{"a": []any{1}}); !errors.Is(err, ErrNilDocument) {
+		t.Fatalf("expected ErrNilDocument, got %v", err)
+	}
+}
+
+// scaledBigJSON repeats testdata/big_json{1,2}.json n times under distinct
+// keys to build a large document and its delta.
+func scaledBigJSON(tb testing.TB, n int) (map[string]any, map[string]any) {
+	tb.Helper()
+	read := func(path string) any {
+		var v any
+		data := mustReadFile(tb, path)
+		if err := json.Unmarshal(data, &v); err != nil {
+			tb.Fatalf("parse %s: %v", path, err)
+		}
+		return v
+	}
+	a, b := map[string]any{}, map[string]any{}
+	for i := 0; i < n; i++ {
+		a["doc"+strconv.Itoa(i)] = read("testdata/big_json1.json")
+		b["doc"+strconv.Itoa(i)] = read("testdata/big_json2.json")
+	}
+	return a, Diff(a, b)
+}
+
+func TestPatchInPlace_BigJSON(t *testing.T) {
+	a, d := scaledBigJSON(t, 3)
+	want, err := Patch(a, d)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	doc := deepCopy(a).(map[string]any)
+	if err := PatchInPlace(doc, d); err != nil {
+		t.Fatalf("PatchInPlace failed: %v", err)
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatal("PatchInPlace differs from Patch")
+	}
+}
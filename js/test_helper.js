@@ -3,7 +3,13 @@
 const jsondiffpatch = require('jsondiffpatch').create({ textDiff: { minLength: 10000 } });
 
 const argv = process.argv;
-if (argv.length > 3) {
+if (argv.length > 4 && argv[2] === '--patch') {
+  // node js/test_helper.js --patch <json> <delta> prints the patched document.
+  const doc = JSON.parse(argv[3]);
+  const delta = JSON.parse(argv[4]);
+  process.stdout.write(JSON.stringify(jsondiffpatch.patch(doc, delta)));
+  process.exit(0);
+} else if (argv.length > 3) {
   const j1 = JSON.parse(argv[2]);
   const j2 = JSON.parse(argv[3]);
   const d = jsondiffpatch.diff(j1, j2);
@@ -14,7 +20,6 @@ if (argv.length > 3) {
   }
   process.exit(0);
 } else {
-  process.stderr.write('usage: node js/test_helper.js <json1> <json2>\n');
+  process.stderr.write('usage: node js/test_helper.js [--patch] <json1> <json2>\n');
   process.exit(1);
 }
-
@@ -16,6 +16,16 @@ import (
 // jsDiff invokes Node + jsondiffpatch via js/test_helper.js.
 // Returns (diff, true, nil) on success; returns (_, false, nil) if helper not available; error otherwise.
 func jsDiff(s1, s2 string) (any, bool, error) {
+	return runJSHelper(s1, s2)
+}
+
+// jsPatch applies delta to doc with jsondiffpatch, with the same results as
+// jsDiff.
+func jsPatch(doc, delta string) (any, bool, error) {
+	return runJSHelper("--patch", doc, delta)
+}
+
+func runJSHelper(args ...string) (any, bool, error) {
 	// Only run when explicitly enabled to avoid CI env issues
 	if os.Getenv("JSONDIFFGO_COMPARE_JS") == "" {
 		return nil, false, nil
@@ -26,11 +36,11 @@ func jsDiff(s1, s2 string) (any, bool, error) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "node", helper, s1, s2)
+	cmd := exec.CommandContext(ctx, "node", append([]string{helper}, args...)...)
 	out, err := cmd.Output()
 	if err != nil {
 		// Likely missing module; skip
-		return nil, false, fmt.Errorf("Node error: %w\ns1: %s\ns2: %s", err, s1, s2)
+		return nil, false, fmt.Errorf("Node error: %w\nargs: %v", err, args)
 	}
 	var v any
 	if len(out) == 0 || string(out) == "null" {
@@ -51,6 +61,13 @@ func TestCompareWithJsondiffpatch(t *testing.T) {
 		{`{"1":[1,{"1":1}]}`, `{"1":[{"1":2}]}`},
 		{`{"a":{"x":1},"b":2}`, `{"a":{"x":2},"b":2}`},
 		{`{"1":[{"1":1}]}`, `{"1":[{"1":2}]}`},
+		{`{"m":[[1,2,3],[4,5,6]]}`, `{"m":[[1,2,4],[4,5,6]]}`},
+		{`{"m":[[1,2],[3,4],[5,6]]}`, `{"m":[[1,2],[3,5],[5,6],[7]]}`},
+		{`{"m":[[[1,2]],[3]]}`, `{"m":[[[1,3]],[3]]}`},
+		{`{"m":[1,[2],3]}`, `{"m":[1,{"x":2},3]}`},
+		// A removed and an inserted object at the same index in place are
+		// diffed against each other.
+		{`{"l":[1,{"a":1}]}`, `{"l":[2,{"a":2}]}`},
 	}
 	for _, tc := range cases {
 		jsd, ok, err := jsDiff(tc.a, tc.b)
@@ -83,6 +100,41 @@ func TestCompareWithJsondiffpatch(t *testing.T) {
 	}
 }
 
+// TestComparePatchWithJsondiffpatch checks that jsondiffpatch patches the
+// deltas Diff produces, and the moves it reads, to the same result as Patch.
+func TestComparePatchWithJsondiffpatch(t *testing.T) {
+	cases := []struct{ doc, delta string }{
+		// Diff leaves {"a":1} unpaired: "true" moves ahead of it, so a nested
+		// diff at index 2 would patch the wrong item.
+		{`{"o":[[],true,{"a":1},"js",true]}`, `{"o":{"_t":"a","_2":[{"a":1},0,0],"_3":["js",0,0],"_4":[true,0,0],"1":[{"e":1}],"2":[{"a":2}]}}`},
+		// Both "x" items are equal; the move takes the one at index 2.
+		{`{"l":["x","y","x"]}`, `{"l":{"_t":"a","_2":["",0,3],"1":["z"]}}`},
+	}
+	for _, tc := range cases {
+		want, ok, err := jsPatch(tc.doc, tc.delta)
+		if err != nil {
+			t.Fatalf("js helper error: %v", err)
+		}
+		if !ok {
+			t.Skip("JSONDIFFGO_COMPARE_JS not set or node helper unavailable; skipping")
+		}
+		var doc, delta map[string]any
+		if err := json.Unmarshal([]byte(tc.doc), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tc.delta), &delta); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Patch(doc, delta)
+		if err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if !reflect.DeepEqual(any(got), want) {
+			t.Fatalf("mismatch with jsondiffpatch\ndoc=%s\ndelta=%s\ngot=%v\nwant=%v", tc.doc, tc.delta, got, want)
+		}
+	}
+}
+
 func TestProperty_CompareWithJsondiffpatch_Quick(t *testing.T) {
 	cfg := &quick.Config{MaxCount: 50, Rand: newPseudoCryptoRand()}
 	prop := func(o1, o2 jsonObject) bool {
@@ -2,9 +2,13 @@ package jsondiffgo
 
 import (
 	"encoding/json"
-	"reflect"
+	"errors"
+	"maps"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 // Json diff implementation ported from the Scala reference.
@@ -37,14 +41,15 @@ func fastEqual(a, b any) bool {
 		}
 	}
 
-	// Fall back to reflection for complex types and type mismatches
-	return reflect.DeepEqual(a, b) && reflect.TypeOf(a) == reflect.TypeOf(b)
+	// Fall back to a structural comparison for containers and other types
+	return deepEqual(a, b)
 }
 
 // Diff computes the JSON diff between two parsed JSON values and returns
 // an object (map) at the root. If there is no difference, an empty object is returned.
-func Diff(a, b any) map[string]any {
-	d := diff(a, b)
+// Options tune how the comparison is performed; the delta format is unchanged.
+func Diff(a, b any, opts ...Option) map[string]any {
+	d := newDiffer(opts).diff(nil, a, b)
 	if d == nil {
 		return map[string]any{}
 	}
@@ -53,7 +58,54 @@ func Diff(a, b any) map[string]any {
 		return m
 	}
 	// Fallback: wrap non-object differences into an object
-	return map[string]any{"_root": d}
+	return map[string]any{rootKey: d}
+}
+
+// differ carries the configuration for a single Diff call.
+type differ struct {
+	opts options
+	// sem bounds the number of extra goroutines; nil when sequential.
+	sem chan struct{}
+	// trackPaths is set when options inspect the location of values.
+	trackPaths bool
+	// customEqual is set when options change what counts as equal, so
+	// comparisons must go through equal instead of fastEqual.
+	customEqual bool
+	// ordered is set by DiffOrdered: object deltas are then *OrderedMap
+	// values when either side is an *OrderedMap.
+	ordered bool
+}
+
+func newDiffer(opts []Option) *differ {
+	d := &differ{}
+	for _, o := range opts {
+		o(&d.opts)
+	}
+	if d.opts.parallelism > 1 {
+		d.sem = make(chan struct{}, d.opts.parallelism)
+	}
+	d.setFlags()
+	return d
+}
+
+// setFlags derives trackPaths and customEqual from the options.
+func (d *differ) setFlags() {
+	d.trackPaths = len(d.opts.ignorePaths) > 0 || len(d.opts.ignoreFuncs) > 0 || len(d.opts.includePaths) > 0
+	for _, c := range d.opts.comparators {
+		d.trackPaths = d.trackPaths || c.scoped
+	}
+	d.trackPaths = d.trackPaths || len(d.opts.unordered) > 0
+	d.customEqual = d.trackPaths || len(d.opts.ignoreKeys) > 0 || len(d.opts.comparators) > 0 ||
+		d.opts.normalizeNumbers
+}
+
+// child returns the pointer to key below p, or nil when no option needs
+// to know where values are located.
+func (d *differ) child(p Pointer, key string) Pointer {
+	if !d.trackPaths {
+		return nil
+	}
+	return p.child(key)
 }
 
 // diff mirrors the behavior of JsonDiff#doDiff in Scala.
@@ -61,26 +113,46 @@ func Diff(a, b any) map[string]any {
 // - nil for no difference (JsNull)
 // - map[string]any for object differences
 // - []any for scalar differences or array/object markers
-func diff(a, b any) any {
+func (d *differ) diff(p Pointer, a, b any) any {
+	if len(d.opts.comparators) > 0 {
+		// A comparator may declare whole subtrees equal; containers it
+		// rejects are still diffed structurally.
+		if eq, ok := d.compare(p, a, b); ok {
+			if eq {
+				return nil
+			}
+			if !isContainer(a) || !isContainer(b) {
+				return []any{a, b}
+			}
+		}
+	}
 	switch aTyped := a.(type) {
 	case []any:
 		if bTyped, ok := b.([]any); ok {
-			return diffArray(aTyped, bTyped)
+			if d.restrictsItems(p) {
+				return d.diffIncludedItems(p, aTyped, bTyped)
+			}
+			return d.diffList(p, aTyped, bTyped)
 		}
-	case map[string]any:
-		if bTyped, ok := b.(map[string]any); ok {
-			return diffObject(aTyped, bTyped)
+	case map[string]any, *OrderedMap:
+		if o2, ok := objectValues(b); ok {
+			o1, _ := objectValues(aTyped)
+			dm := d.diffObject(p, o1, o2)
+			if m, ok := dm.(map[string]any); ok && d.ordered && isOrdered(a, b) {
+				return orderDelta(m, a, b)
+			}
+			return dm
 		}
 	}
 
 	// Scalars or type mismatch
-	if fastEqual(a, b) {
+	if d.equal(p, a, b) {
 		return nil
 	}
 	return []any{a, b}
 }
 
-func diffObject(o1, o2 map[string]any) any {
+func (d *differ) diffObject(p Pointer, o1, o2 map[string]any) any {
 	diffMap := map[string]any{}
 
 	// Union of keys
@@ -92,20 +164,41 @@ func diffObject(o1, o2 map[string]any) any {
 		keys[k] = struct{}{}
 	}
 
+	// Keys present on both sides holding containers are the expensive ones;
+	// they are collected and handed to forEach so they can run concurrently.
+	var nested []string
 	for k := range keys {
 		v1, ok1 := o1[k]
 		v2, ok2 := o2[k]
-		var d any
+		if d.customEqual && d.ignored(d.child(p, k), k, v1, v2) {
+			continue
+		}
+		var dv any
 		switch {
 		case ok1 && ok2:
-			d = diff(v1, v2)
+			if d.sem != nil && isContainer(v1) && isContainer(v2) {
+				nested = append(nested, k)
+				continue
+			}
+			dv = d.diff(d.child(p, k), v1, v2)
 		case ok1 && !ok2:
-			d = []any{v1, float64(0), float64(0)}
+			dv = []any{v1, float64(0), float64(0)}
 		case !ok1 && ok2:
-			d = []any{v2}
+			dv = []any{v2}
+		}
+		if dv != nil {
+			diffMap[k] = dv
 		}
-		if d != nil {
-			diffMap[k] = d
+	}
+
+	results := make([]any, len(nested))
+	d.forEach(len(nested), func(i int) {
+		k := nested[i]
+		results[i] = d.diff(d.child(p, k), o1[k], o2[k])
+	})
+	for i, k := range nested {
+		if results[i] != nil {
+			diffMap[k] = results[i]
 		}
 	}
 
@@ -115,16 +208,59 @@ func diffObject(o1, o2 map[string]any) any {
 	return diffMap
 }
 
+func isContainer(v any) bool {
+	switch v.(type) {
+	case map[string]any, *OrderedMap, []any:
+		return true
+	}
+	return false
+}
+
+// forEach calls fn for every index in [0, n). When the differ is parallel,
+// calls are handed to a new goroutine whenever a worker slot is free and run
+// inline otherwise, so nested calls never block waiting for a slot.
+func (d *differ) forEach(n int, fn func(i int)) {
+	if d.sem == nil || n < 2 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case d.sem <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-d.sem
+					wg.Done()
+				}()
+				fn(i)
+			}(i)
+		default:
+			fn(i)
+		}
+	}
+	wg.Wait()
+}
+
 type arrayAcc struct {
 	count        int
 	deletedCount int
 	acc          map[string]any
 }
 
-func diffArray(l1, l2 []any) any {
+func (d *differ) diffArray(p Pointer, l1, l2 []any) any {
 	// Use Myers to diff arrays
-	edits := Myers(l1, l2)
+	edits := d.align(p, l1, l2)
+	if d.opts.similarity > 0 {
+		return d.diffSimilar(p, l1, l2, edits)
+	}
 
+	// count and deletedCount track the positions in l2 and l1; the values
+	// are taken from the inputs so that items matched by a custom equality
+	// keep their own content.
 	acc := arrayAcc{count: 0, deletedCount: 0, acc: map[string]any{}}
 	for _, e := range edits {
 		switch v := e.(type) {
@@ -133,15 +269,15 @@ func diffArray(l1, l2 []any) any {
 			acc.count += n
 			acc.deletedCount += n
 		case Delete:
-			for _, it := range v.Val {
+			for range v.Val {
 				key := "_" + strconv.Itoa(acc.deletedCount)
-				acc.acc[key] = []any{it, float64(0), float64(0)}
+				acc.acc[key] = []any{l1[acc.deletedCount], float64(0), float64(0)}
 				acc.deletedCount++
 			}
 		case Insert:
-			for _, it := range v.Val {
+			for range v.Val {
 				key := strconv.Itoa(acc.count)
-				acc.acc[key] = []any{it}
+				acc.acc[key] = []any{l2[acc.count]}
 				acc.count++
 			}
 		}
@@ -162,7 +298,7 @@ func diffArray(l1, l2 []any) any {
 	if len(deleted) == 0 {
 		out = acc.acc
 	} else {
-		out = allChecked(checked, deleted)
+		out = d.allChecked(p, checked, deleted)
 		// filter nils
 		for k, v := range out {
 			if v == nil {
@@ -178,11 +314,21 @@ func diffArray(l1, l2 []any) any {
 	return out
 }
 
-// splitUnderscoreMap implements the Scala splitUnderscoreMap predicate.
+// diffList diffs two arrays, as unordered multisets where UnorderedArrays
+// asks for it.
+func (d *differ) diffList(p Pointer, l1, l2 []any) any {
+	if u, ok := d.unorderedAt(p); ok {
+		return d.diffUnordered(p, u, l1, l2)
+	}
+	return d.diffArray(p, l1, l2)
+}
+
+// splitUnderscoreMap implements the Scala splitUnderscoreMap predicate,
+// extended to deleted arrays as jsondiffpatch matches them by position too.
 func splitUnderscoreMap(key string, value any) bool {
 	if len(key) > 0 && key[0] == '_' {
 		if arr, ok := value.([]any); ok && len(arr) == 3 {
-			if _, isObj := arr[0].(map[string]any); isObj {
+			if isContainer(arr[0]) {
 				return isZero(arr[1]) && isZero(arr[2])
 			}
 		}
@@ -229,11 +375,11 @@ func toNumber(v any) (float64, bool) {
 	}
 }
 
-// allChecked transforms insertions of objects combined with corresponding deletions
-// into nested diffs, mirroring the Scala logic. This is a key part of the
-// jsondiffpatch algorithm, which aims to produce more semantic diffs for
-// arrays of objects.
-func allChecked(checked, deleted map[string]any) map[string]any {
+// allChecked transforms insertions of objects or arrays combined with
+// corresponding deletions into nested diffs, mirroring the Scala logic. This
+// is a key part of the jsondiffpatch algorithm, which aims to produce more
+// semantic diffs for arrays of objects and nested arrays such as matrices.
+func (d *differ) allChecked(p Pointer, checked, deleted map[string]any) map[string]any {
 	result := map[string]any{}
 
 	// Work on a copy of deleted for mutation
@@ -242,18 +388,42 @@ func allChecked(checked, deleted map[string]any) map[string]any {
 		del[k] = v
 	}
 
+	type pair struct {
+		key       string
+		old, next any
+	}
+	// A paired item stays among the survivors instead of being replaced, so
+	// Patch finds it at index k only if as many deletions as insertions
+	// precede it.
+	var dels, ins []int
+	for _, m := range []map[string]any{checked, deleted} {
+		for k := range m {
+			if strings.HasPrefix(k, "_") {
+				if idx, err := strconv.Atoi(k[1:]); err == nil {
+					dels = append(dels, idx)
+				}
+			} else if idx, err := strconv.Atoi(k); err == nil {
+				ins = append(ins, idx)
+			}
+		}
+	}
+	sort.Ints(dels)
+	sort.Ints(ins)
+	inPlace := func(k string) bool {
+		idx, err := strconv.Atoi(k)
+		return err == nil && sort.SearchInts(dels, idx) == sort.SearchInts(ins, idx)
+	}
+
+	var pairs []pair
 	for k, v := range checked {
-		// Only transform entries like i -> [ {..} ]
+		// Only transform entries like i -> [ {..} ] or i -> [ [..] ]
 		if arr, ok := v.([]any); ok && len(arr) == 1 {
-			if obj, ok2 := arr[0].(map[string]any); ok2 {
+			if obj := arr[0]; isContainer(obj) {
 				negKey := "_" + k
 				if dv, ok3 := del[negKey]; ok3 {
 					if darr, ok4 := dv.([]any); ok4 && len(darr) == 3 {
-						if dobj, ok5 := darr[0].(map[string]any); ok5 && isZero(darr[1]) && isZero(darr[2]) {
-							nested := diff(dobj, obj)
-							if nested != nil {
-								result[k] = nested
-							}
+						if dobj := darr[0]; isContainer(dobj) && isZero(darr[1]) && isZero(darr[2]) && inPlace(k) {
+							pairs = append(pairs, pair{key: k, old: dobj, next: obj})
 							delete(del, negKey)
 							continue
 						}
@@ -265,6 +435,18 @@ func allChecked(checked, deleted map[string]any) map[string]any {
 		result[k] = v
 	}
 
+	// Paired items are independent of each other, so their nested diffs may
+	// be computed concurrently.
+	nested := make([]any, len(pairs))
+	d.forEach(len(pairs), func(i int) {
+		nested[i] = d.diff(d.child(p, pairs[i].key), pairs[i].old, pairs[i].next)
+	})
+	for i, p := range pairs {
+		if nested[i] != nil {
+			result[p.key] = nested[i]
+		}
+	}
+
 	// Append remaining deleted entries
 	for k, v := range del {
 		result[k] = v
@@ -274,31 +456,78 @@ func allChecked(checked, deleted map[string]any) map[string]any {
 
 // Patch applies a jsondiffpatch-style diff to the provided object and returns the patched object.
 // Both inputs must be JSON objects (map[string]any).
+// Neither input is modified, and the result shares no memory with them:
+// obj is deep-copied before the diff is applied, and values taken from diff
+// are deep-copied as they are inserted. Use PatchInPlace to avoid the copy.
 func Patch(obj map[string]any, diff map[string]any) (map[string]any, error) {
-	return doPatch(obj, diff)
+	out, _ := deepCopy(obj).(map[string]any)
+	if out == nil {
+		out = map[string]any{}
+	}
+	return doPatch(out, diff, patchOwned)
 }
 
-func doPatch(m1 map[string]any, d1 map[string]any) (map[string]any, error) {
-	// Preprocess: turn [new_value] entries into new_value directly
-	pre := map[string]any{}
-	for k, v := range d1 {
-		if arr, ok := v.([]any); ok && len(arr) == 1 {
-			pre[k] = arr[0]
-		} else {
-			pre[k] = v
-		}
+// PatchInPlace applies a jsondiffpatch-style diff by mutating doc instead of
+// copying it. Objects and arrays touched by the diff are updated in place;
+// new containers are only allocated where the structure changes, e.g. when
+// an array grows beyond its capacity or a value changes type.
+//
+// Aliasing: doc and every container reachable from it may be modified, so
+// any other references into doc observe the patch, including partially
+// applied changes when an error is returned. Values inserted by the diff
+// are not copied and remain shared with diff.
+func PatchInPlace(doc map[string]any, diff map[string]any) error {
+	if doc == nil {
+		return ErrNilDocument
+	}
+	_, err := doPatch(doc, diff, patchInPlace)
+	return err
+}
+
+// ErrNilDocument is returned by PatchInPlace for a nil document, which
+// cannot be updated in place.
+var ErrNilDocument = errors.New("jsondiffgo: cannot patch a nil document in place")
+
+// patchMode selects whether patching copies the containers it changes or
+// updates them in place.
+type patchMode int
+
+const (
+	// patchCopy copies each object and array before changing it and
+	// deep-copies values taken from the diff.
+	patchCopy patchMode = iota
+	// patchInPlace updates containers in place and shares values taken from
+	// the diff.
+	patchInPlace
+	// patchOwned updates containers in place, like patchInPlace, but
+	// deep-copies values taken from the diff. It is for documents that are
+	// already a private copy.
+	patchOwned
+)
+
+// take returns v, which comes from the diff, for insertion into the document.
+func (m patchMode) take(v any) any {
+	if m != patchInPlace {
+		return deepCopy(v)
 	}
+	return v
+}
 
-	// Merge
-	out := map[string]any{}
-	// start with original
-	for k, v := range m1 {
-		out[k] = v
+func doPatch(out map[string]any, d1 map[string]any, mode patchMode) (map[string]any, error) {
+	if mode == patchCopy {
+		out = maps.Clone(out)
+		if out == nil {
+			out = map[string]any{}
+		}
 	}
-	for k, v := range pre {
+	for k, v := range d1 {
+		// Turn [new_value] entries into new_value directly
+		if arr, ok := v.([]any); ok && len(arr) == 1 {
+			v = arr[0]
+		}
 		existing, has := out[k]
 		if has {
-			merged, ok, remove, err := doPatchMerge(existing, v)
+			merged, ok, remove, err := doPatchMerge(existing, v, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -310,11 +539,11 @@ func doPatch(m1 map[string]any, d1 map[string]any) (map[string]any, error) {
 				out[k] = merged
 			} else {
 				// replace with provided when not specially handled
-				out[k] = v
+				out[k] = mode.take(v)
 			}
 		} else {
 			// new key: assign value as-is (it's a concrete value, not a diff)
-			out[k] = v
+			out[k] = mode.take(v)
 		}
 	}
 	return out, nil
@@ -322,37 +551,37 @@ func doPatch(m1 map[string]any, d1 map[string]any) (map[string]any, error) {
 
 // doPatchMerge applies one diff value vDiff to an existing value vMap.
 // Returns (newValue, replaced?, removeKey?, error?)
-func doPatchMerge(vMap, vDiff any) (any, bool, bool, error) {
+func doPatchMerge(vMap, vDiff any, mode patchMode) (any, bool, bool, error) {
 	// Case: [old, new]
 	if arr, ok := vDiff.([]any); ok {
 		if len(arr) == 2 {
 			if fastEqual(arr[0], vMap) {
-				return arr[1], true, false, nil
+				return mode.take(arr[1]), true, false, nil
 			}
 			// if old doesn't match, still replace with new
-			return arr[1], true, false, nil
+			return mode.take(arr[1]), true, false, nil
 		}
 		if len(arr) == 3 && isZero(arr[1]) && isZero(arr[2]) {
 			// deletion marker for object key
 			return nil, false, true, nil
 		}
 		// otherwise treat as replacement value
-		return vDiff, true, false, nil
+		return mode.take(vDiff), true, false, nil
 	}
 
 	// Case: object
-	if m, ok := vDiff.(map[string]any); ok {
+	if m, ok := objectValues(vDiff); ok {
 		if t, hasT := m["_t"]; hasT && t == "a" {
 			// array diff
 			// remove marker before applying
-			patched, err := applyArrayPatch(asArray(vMap), m)
+			patched, err := applyArrayPatch(asArray(vMap), m, mode)
 			if err != nil {
 				return nil, false, false, err
 			}
 			return patched, true, false, nil
 		}
 		// nested object diff
-		patched, err := doPatch(asMap(vMap), m)
+		patched, err := patchObject(vMap, vDiff, mode)
 		if err != nil {
 			return nil, false, false, err
 		}
@@ -360,7 +589,7 @@ func doPatchMerge(vMap, vDiff any) (any, bool, bool, error) {
 	}
 
 	// Default: replace
-	return vDiff, true, false, nil
+	return mode.take(vDiff), true, false, nil
 }
 
 func asArray(v any) []any {
@@ -379,8 +608,39 @@ func asMap(v any) map[string]any {
 	return map[string]any{}
 }
 
+// deepCopy copies the maps and slices of a parsed JSON value. Other values
+// are immutable in the JSON model and are shared.
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if t == nil {
+			return t
+		}
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			out[k] = deepCopy(x)
+		}
+		return out
+	case *OrderedMap:
+		if t == nil {
+			return t
+		}
+		return &OrderedMap{keys: slices.Clone(t.keys), values: deepCopy(t.values).(map[string]any), right: t.right}
+	case []any:
+		if t == nil {
+			return t
+		}
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = deepCopy(x)
+		}
+		return out
+	}
+	return v
+}
+
 // applyArrayPatch implements the array patching logic for jsondiffpatch-style diffs.
-func applyArrayPatch(list []any, diff map[string]any) ([]any, error) {
+func applyArrayPatch(list []any, diff map[string]any, mode patchMode) ([]any, error) {
 	// Make a shallow copy of diff and remove _t
 	d2 := map[string]any{}
 	for k, v := range diff {
@@ -394,18 +654,26 @@ func applyArrayPatch(list []any, diff map[string]any) ([]any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if mode == patchCopy {
+		list = slices.Clone(list)
+	}
 
-	// Remove deleted indices
+	// Remove deleted indices, remembering where survivors came from when
+	// moves need to find them again.
+	var kept []int
+	if len(moves) > 0 {
+		kept = survivingIndices(len(list), deletedIdx)
+	}
 	filtered := applyArrayDeletions(list, deletedIdx)
 
 	// Apply moves
-	res, err := applyArrayMoves(filtered, list, moves)
+	res, err := applyArrayMoves(filtered, kept, moves)
 	if err != nil {
 		return nil, err
 	}
 
 	// Apply remaining operations
-	res, err = applyArrayRemaining(res, remaining)
+	res, err = applyArrayRemaining(res, remaining, mode)
 	if err != nil {
 		return nil, err
 	}
@@ -450,54 +718,60 @@ func parseArrayDiff(diff map[string]any) (map[int]struct{}, []moveOp, map[string
 	return deletedIdx, moves, remaining, nil
 }
 
+// applyArrayDeletions removes the deleted indices from list, reusing its
+// backing array.
 func applyArrayDeletions(list []any, deletedIdx map[int]struct{}) []any {
-	filtered := make([]any, 0, len(list))
+	if len(deletedIdx) == 0 {
+		return list
+	}
+	w := 0
 	for i, val := range list {
 		if _, isDel := deletedIdx[i]; !isDel {
-			filtered = append(filtered, val)
+			list[w] = val
+			w++
+		}
+	}
+	clear(list[w:])
+	return list[:w]
+}
+
+// survivingIndices lists the original indices of a list of length n that
+// remain after deletedIdx is removed.
+func survivingIndices(n int, deletedIdx map[int]struct{}) []int {
+	kept := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if _, isDel := deletedIdx[i]; !isDel {
+			kept = append(kept, i)
 		}
 	}
-	return filtered
+	return kept
 }
 
-func applyArrayMoves(res, orig []any, moves []moveOp) ([]any, error) {
+// applyArrayMoves moves items by their original index. kept[i] is the
+// original index of res[i]; tracking identity rather than value keeps moves
+// correct when the array holds duplicates.
+func applyArrayMoves(res []any, kept []int, moves []moveOp) ([]any, error) {
 	if len(moves) > 0 {
-		// capture original list for identity
 		sort.Slice(moves, func(i, j int) bool { return moves[i].dest < moves[j].dest })
 		for _, m := range moves {
-			if m.src < 0 || m.src >= len(orig) {
-				continue
-			}
-			val := orig[m.src]
-			// find current index of val in res
-			cur := -1
-			for i := range res {
-				if fastEqual(res[i], val) {
-					cur = i
-					break
-				}
-			}
+			cur := slices.Index(kept, m.src)
 			if cur == -1 {
 				continue
 			}
+			val := res[cur]
 			// remove at cur
-			res = append(res[:cur], res[cur+1:]...)
+			res = slices.Delete(res, cur, cur+1)
+			kept = slices.Delete(kept, cur, cur+1)
 			// insert at dest
-			if m.dest < 0 {
-				m.dest = 0
-			}
-			if m.dest >= len(res) {
-				res = append(res, val)
-			} else {
-				res = append(res[:m.dest+1], res[m.dest:]...)
-				res[m.dest] = val
-			}
+			dest := min(max(m.dest, 0), len(res))
+			res = slices.Insert(res, dest, val)
+			kept = slices.Insert(kept, dest, m.src)
 		}
 	}
 	return res, nil
 }
 
-func applyArrayRemaining(res []any, remaining map[string]any) ([]any, error) {
+func applyArrayRemaining(res []any, remaining map[string]any, mode patchMode) ([]any, error) {
 	type kv struct {
 		idx int
 		val any
@@ -513,10 +787,10 @@ func applyArrayRemaining(res []any, remaining map[string]any) ([]any, error) {
 
 	for _, op := range ops {
 		switch v := op.val.(type) {
-		case map[string]any:
+		case map[string]any, *OrderedMap:
 			// nested diff at index
 			if op.idx >= 0 && op.idx < len(res) {
-				patched, err := doPatch(asMap(res[op.idx]), v)
+				patched, _, _, err := doPatchMerge(res[op.idx], v, mode)
 				if err != nil {
 					return nil, err
 				}
@@ -525,7 +799,7 @@ func applyArrayRemaining(res []any, remaining map[string]any) ([]any, error) {
 		case []any:
 			if len(v) == 1 {
 				// insert at index
-				val := v[0]
+				val := mode.take(v[0])
 				if op.idx < 0 {
 					op.idx = 0
 				}
@@ -538,7 +812,7 @@ func applyArrayRemaining(res []any, remaining map[string]any) ([]any, error) {
 			} else if len(v) == 2 {
 				// replace at index with new value
 				if op.idx >= 0 && op.idx < len(res) {
-					res[op.idx] = v[1]
+					res[op.idx] = mode.take(v[1])
 				}
 			}
 		}
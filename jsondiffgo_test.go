@@ -241,3 +241,87 @@ func TestJsonPatch_DeletedKeyWorks(t *testing.T) {
 		t.Fatalf("patch mismatch: got=%v want=%v", patched, s2)
 	}
 }
+
+func TestJsonPatch_DoesNotShareArrays(t *testing.T) {
+	// Spare capacity in the original slice must not be written by inserts.
+	list := make([]any, 3, 8)
+	list[0], list[1], list[2] = 1.0, 2.0, 3.0
+	inserted := map[string]any{"x": 1.0}
+	obj := map[string]any{"l": list}
+	diff := map[string]any{"l": map[string]any{"1": []any{inserted}, "_2": []any{3.0, 0, 0}, "_t": "a"}}
+	patched, err := Patch(obj, diff)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(list[:4], []any{1.0, 2.0, 3.0, nil}) {
+		t.Fatalf("Patch wrote into the original array: %v", list[:4])
+	}
+	patched["l"].([]any)[1].(map[string]any)["x"] = 2.0
+	if inserted["x"] != 1.0 {
+		t.Fatal("inserted value is shared with the diff")
+	}
+}
+
+func TestJsonDiff_PairsOnlyItemsThatStayInPlace(t *testing.T) {
+	// {"a":1} cannot become {"a":2} in place: "true" moves ahead of it, so
+	// Patch would apply the nested diff to the wrong item.
+	a := map[string]any{"o": []any{[]any{}, true, map[string]any{"a": 1.0}, "js", true}}
+	b := map[string]any{"o": []any{[]any{}, map[string]any{"e": 1.0}, map[string]any{"a": 2.0}, true}}
+	patched, err := Patch(a, Diff(a, b))
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(patched, b) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, b)
+	}
+}
+
+func TestJsonPatch_MovesDuplicateItemsByIndex(t *testing.T) {
+	// Both "x" items are equal; the move must take the one at index 2.
+	a := map[string]any{"l": []any{"x", "y", "x"}}
+	d := map[string]any{"l": map[string]any{"_t": "a", "_2": []any{"", 0.0, 3.0}, "1": []any{"z"}}}
+	patched, err := Patch(a, d)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	want := map[string]any{"l": []any{"x", "z", "x", "y"}}
+	if !reflect.DeepEqual(patched, want) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, want)
+	}
+}
+
+func TestJsonDiff_NestedArrays(t *testing.T) {
+	a := parseJSON(t, `{"m":[[1,2,3],[4,5,6],[7,8,9]]}`).(map[string]any)
+	b := parseJSON(t, `{"m":[[1,2,4],[4,5,6],[7,[8],9,10]]}`).(map[string]any)
+	expected := parseJSON(t, `{"m":{"_t":"a",
+		"0":{"_t":"a","2":[4],"_2":[3,0,0]},
+		"2":{"_t":"a","1":[[8]],"_1":[8,0,0],"3":[10]}}}`)
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, expected)
+	}
+	patched, err := Patch(a, got)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(patched, b) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, b)
+	}
+}
+
+func TestJsonDiff_ArrayReplacedByObjectInArray(t *testing.T) {
+	a := parseJSON(t, `{"m":[1,[2],3]}`).(map[string]any)
+	b := parseJSON(t, `{"m":[1,{"x":2},3]}`).(map[string]any)
+	expected := parseJSON(t, `{"m":{"_t":"a","1":[[2],{"x":2}]}}`)
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, expected)
+	}
+	patched, err := Patch(a, got)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(patched, b) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, b)
+	}
+}
@@ -0,0 +1,204 @@
+package jsondiffgo
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Conflict is a value that both sides of a three-way merge changed in
+// different ways. Base, Ours and Theirs hold the competing versions; a value
+// that is missing on one side, because it was deleted or never added, is nil.
+// For conflicting array regions they hold the items of the region.
+type Conflict struct {
+	Path   Pointer
+	Base   any
+	Ours   any
+	Theirs any
+}
+
+// Merge3 merges two documents derived from a common base. Changes made by
+// only one side are applied; changes made by both sides are kept when they
+// agree and reported as conflicts when they do not, in which case merged
+// holds our version.
+//
+// Objects are merged key by key. Arrays are aligned on the base with the
+// Myers algorithm, so insertions and deletions made by the two sides at
+// different positions all survive. When both sides insert different items at
+// the same position, ours come first and theirs follow. Items changed in
+// place by both sides are merged recursively.
+//
+// Options change what counts as a change exactly as they do for Diff: values
+// are compared and arrays aligned the way Diff(base, ours, opts...) compares
+// and aligns them, and keys the options ignore take our version without
+// conflict. Merge3 walks the three documents instead of transforming the two
+// deltas, because a conflicting array region, such as an item both sides
+// replaced with different ones, is a deletion plus insertions in each delta
+// that Transform would combine without a conflict.
+//
+// The merged document shares no maps or slices with the inputs. Conflicts are
+// reported in document order, with object keys visited in sorted order.
+func Merge3(base, ours, theirs any, opts ...Option) (merged any, conflicts []Conflict) {
+	m := &merger{d: newDiffer(opts)}
+	merged = m.merge(Pointer{}, base, ours, theirs)
+	return deepCopy(merged), m.conflicts
+}
+
+type merger struct {
+	d         *differ
+	conflicts []Conflict
+}
+
+func (m *merger) conflict(p Pointer, base, ours, theirs any) {
+	m.conflicts = append(m.conflicts, Conflict{Path: p, Base: base, Ours: ours, Theirs: theirs})
+}
+
+func (m *merger) merge(p Pointer, base, ours, theirs any) any {
+	switch {
+	case m.d.equal(p, ours, theirs), m.d.equal(p, base, theirs):
+		return ours
+	case m.d.equal(p, base, ours):
+		return theirs
+	}
+	if b, ok := base.(map[string]any); ok {
+		o, ok1 := ours.(map[string]any)
+		t, ok2 := theirs.(map[string]any)
+		if ok1 && ok2 {
+			return m.mergeObject(p, b, o, t)
+		}
+	}
+	if b, ok := base.([]any); ok {
+		o, ok1 := ours.([]any)
+		t, ok2 := theirs.([]any)
+		if ok1 && ok2 {
+			return m.mergeArray(p, b, o, t)
+		}
+	}
+	m.conflict(p, base, ours, theirs)
+	return ours
+}
+
+func (m *merger) mergeObject(p Pointer, base, ours, theirs map[string]any) map[string]any {
+	keys := make([]string, 0, len(base)+len(ours)+len(theirs))
+	for _, obj := range []map[string]any{base, ours, theirs} {
+		for k := range obj {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]any, len(ours))
+	for i, k := range keys {
+		if i > 0 && keys[i-1] == k {
+			continue
+		}
+		bv, inBase := base[k]
+		ov, inOurs := ours[k]
+		tv, inTheirs := theirs[k]
+		if m.d.ignored(p.child(k), k, ov, tv) {
+			if inOurs {
+				out[k] = ov
+			}
+			continue
+		}
+		switch {
+		case inOurs && inTheirs:
+			if !inBase {
+				// Objects added on both sides are merged as if they had been
+				// empty before.
+				_, ok1 := ov.(map[string]any)
+				_, ok2 := tv.(map[string]any)
+				if ok1 && ok2 {
+					bv = map[string]any{}
+				}
+			}
+			out[k] = m.merge(p.child(k), bv, ov, tv)
+		case inOurs:
+			// Added by us, or deleted by them.
+			if inBase && !m.d.equal(p.child(k), bv, ov) {
+				m.conflict(p.child(k), bv, ov, nil)
+				out[k] = ov
+			} else if !inBase {
+				out[k] = ov
+			}
+		case inTheirs:
+			// Added by them, or deleted by us.
+			if inBase && !m.d.equal(p.child(k), bv, tv) {
+				m.conflict(p.child(k), bv, nil, tv)
+			} else if !inBase {
+				out[k] = tv
+			}
+		}
+	}
+	return out
+}
+
+// mergeArray merges arrays diff3 style: items kept by both sides anchor the
+// result and the regions between anchors are resolved one by one.
+func (m *merger) mergeArray(p Pointer, base, ours, theirs []any) []any {
+	matchOurs := m.matchBase(p, base, ours)
+	matchTheirs := m.matchBase(p, base, theirs)
+
+	out := make([]any, 0, max(len(ours), len(theirs)))
+	i, jo, jt := 0, 0, 0
+	for {
+		s := i
+		for s < len(base) && (matchOurs[s] < 0 || matchTheirs[s] < 0) {
+			s++
+		}
+		endOurs, endTheirs := len(ours), len(theirs)
+		if s < len(base) {
+			endOurs, endTheirs = matchOurs[s], matchTheirs[s]
+		}
+		out = m.mergeRegion(p, out, base[i:s], ours[jo:endOurs], theirs[jt:endTheirs])
+		if s == len(base) {
+			return out
+		}
+		out = append(out, ours[endOurs])
+		i, jo, jt = s+1, endOurs+1, endTheirs+1
+	}
+}
+
+func (m *merger) mergeRegion(p Pointer, out, base, ours, theirs []any) []any {
+	eq := func(a, b any) bool { return m.d.equal(p, a, b) }
+	switch {
+	case seqEqual(ours, theirs, eq), seqEqual(base, theirs, eq):
+		return append(out, ours...)
+	case seqEqual(base, ours, eq):
+		return append(out, theirs...)
+	case len(base) == 0:
+		return append(append(out, ours...), theirs...)
+	case len(base) == len(ours) && len(base) == len(theirs):
+		for k := range base {
+			out = append(out, m.merge(p.child(strconv.Itoa(len(out))), base[k], ours[k], theirs[k]))
+		}
+		return out
+	}
+	m.conflict(p.child(strconv.Itoa(len(out))), base, ours, theirs)
+	return append(out, ours...)
+}
+
+// matchBase aligns side with base the way Diff aligns arrays and returns, for
+// every base item, the index of the same item in side or -1 when side dropped
+// it.
+func (m *merger) matchBase(p Pointer, base, side []any) []int {
+	match := make([]int, len(base))
+	i, j := 0, 0
+	for _, e := range m.d.align(p, base, side) {
+		switch v := e.(type) {
+		case Equal:
+			for range v.Val {
+				match[i] = j
+				i++
+				j++
+			}
+		case Delete:
+			for range v.Val {
+				match[i] = -1
+				i++
+			}
+		case Insert:
+			j += len(v.Val)
+		}
+	}
+	return match
+}
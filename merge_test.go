@@ -0,0 +1,130 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3_DisjointObjectChanges(t *testing.T) {
+	base := map[string]any{"title": "draft", "tags": map[string]any{"a": true}, "gone": 1.0}
+	ours := map[string]any{"title": "final", "tags": map[string]any{"a": true}, "gone": 1.0}
+	theirs := map[string]any{"title": "draft", "tags": map[string]any{"a": true, "b": true}, "new": "x"}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	want := map[string]any{"title": "final", "tags": map[string]any{"a": true, "b": true}, "new": "x"}
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, want)
+	}
+}
+
+func TestMerge3_Conflicts(t *testing.T) {
+	base := map[string]any{"a": 1.0, "b": map[string]any{"c": 1.0}, "d": 1.0}
+	ours := map[string]any{"a": 2.0, "b": map[string]any{"c": 2.0}}
+	theirs := map[string]any{"a": 3.0, "d": 1.0}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	want := []Conflict{
+		{Path: Pointer{"a"}, Base: 1.0, Ours: 2.0, Theirs: 3.0},
+		{Path: Pointer{"b"}, Base: map[string]any{"c": 1.0}, Ours: map[string]any{"c": 2.0}},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Fatalf("unexpected conflicts. got=%v want=%v", conflicts, want)
+	}
+	// Ours wins conflicts; "d" was deleted by us and left alone by them.
+	wantMerged := map[string]any{"a": 2.0, "b": map[string]any{"c": 2.0}}
+	if !reflect.DeepEqual(merged, wantMerged) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, wantMerged)
+	}
+}
+
+func TestMerge3_ArrayInsertsAtDifferentPositions(t *testing.T) {
+	base := []any{"a", "b", "c", "d"}
+	ours := []any{"x", "a", "b", "c", "d"}
+	theirs := []any{"a", "b", "c", "y", "d"}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	want := []any{"x", "a", "b", "c", "y", "d"}
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, want)
+	}
+}
+
+func TestMerge3_ArrayDeletesAndSamePositionInserts(t *testing.T) {
+	base := []any{"a", "b", "c"}
+	ours := []any{"a", "o", "b"}
+	theirs := []any{"a", "t", "b", "c"}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := []any{"a", "o", "t", "b"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, want)
+	}
+}
+
+func TestMerge3_ArrayItemsMergedInPlace(t *testing.T) {
+	base := map[string]any{"l": []any{map[string]any{"n": 1.0, "v": "a"}, "keep"}}
+	ours := map[string]any{"l": []any{map[string]any{"n": 2.0, "v": "a"}, "keep"}}
+	theirs := map[string]any{"l": []any{map[string]any{"n": 1.0, "v": "b"}, "keep", "more"}}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := map[string]any{"l": []any{map[string]any{"n": 2.0, "v": "b"}, "keep", "more"}}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, want)
+	}
+}
+
+func TestMerge3_ArrayConflict(t *testing.T) {
+	base := map[string]any{"l": []any{"a", "b", "c"}}
+	ours := map[string]any{"l": []any{"a", "x", "c"}}
+	theirs := map[string]any{"l": []any{"a", "y", "z", "c"}}
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	want := []Conflict{{Path: Pointer{"l", "1"}, Base: []any{"b"}, Ours: []any{"x"}, Theirs: []any{"y", "z"}}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Fatalf("unexpected conflicts. got=%v want=%v", conflicts, want)
+	}
+	if !reflect.DeepEqual(merged, ours) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, ours)
+	}
+}
+
+func TestMerge3_DoesNotShareMemory(t *testing.T) {
+	base := map[string]any{}
+	ours := map[string]any{"l": []any{1.0}}
+	merged, _ := Merge3(base, ours, base)
+	scribble(merged)
+	if !reflect.DeepEqual(ours, map[string]any{"l": []any{1.0}}) {
+		t.Fatalf("merge result aliases its input: %v", ours)
+	}
+}
+
+func TestMerge3_Options(t *testing.T) {
+	base := parseJSON(t, `{"l":[{"id":1,"etag":"a"},{"id":2,"etag":"a"}],"n":1.0,"at":"mon"}`)
+	ours := parseJSON(t, `{"l":[{"id":1,"etag":"b"},{"id":2,"etag":"b"},{"id":3}],"n":1.0,"at":"tue"}`)
+	theirs := parseJSON(t, `{"l":[{"id":0},{"id":1,"etag":"c"},{"id":2,"etag":"c"}],"n":1.0000001,"at":"wed"}`)
+
+	// Without options every etag and timestamp change conflicts.
+	if _, conflicts := Merge3(base, ours, theirs); len(conflicts) == 0 {
+		t.Fatal("expected conflicts without options")
+	}
+	merged, conflicts := Merge3(base, ours, theirs, IgnoreKeys("etag", "at"), WithComparator(AbsoluteTolerance(1e-3)))
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	want := parseJSON(t, `{"l":[{"id":0},{"id":1,"etag":"b"},{"id":2,"etag":"b"},{"id":3}],"n":1.0,"at":"tue"}`)
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("unexpected merge. got=%v want=%v", merged, want)
+	}
+}
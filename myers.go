@@ -47,50 +47,107 @@ type Continue matchable.ValuedMatchable[_Process, Path]
 // Myers computes a compact diff between two sequences using the Myers algorithm.
 // It is a port of the Scala implementation from jsondiffpatch.
 // The algorithm finds the shortest edit script (SES) between two sequences.
+// Elements are compared like reflect.DeepEqual, except that JSON objects are
+// compared by content: key order does not matter, and a map[string]any equals
+// an *OrderedMap with the same entries.
 func Myers(oldseq, newseq []any) []MyerDiff {
+	return myers(oldseq, newseq, deepEqual)
+}
+
+// equalFunc reports whether an element of the old sequence matches an
+// element of the new sequence.
+type equalFunc func(a, b any) bool
+
+// deepEqual is reflect.DeepEqual, except that JSON objects are equal
+// regardless of key order, whether they are maps or *OrderedMap values.
+func deepEqual(a, b any) bool {
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) || (av == nil) != (bv == nil) {
+			return false
+		}
+		for i := range av {
+			if !deepEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok && (av == nil) != (bv == nil) {
+			return false
+		}
+		bv, ok := objectValues(b)
+		return ok && objectsEqual(av, bv)
+	case *OrderedMap:
+		am, _ := objectValues(av)
+		bm, ok := objectValues(b)
+		return ok && objectsEqual(am, bm)
+	case string, float64, bool, nil:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func objectsEqual(a, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || !deepEqual(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// myers is Myers with a caller supplied element comparison, used for both
+// snake following and the compaction rules.
+func myers(oldseq, newseq []any, eq equalFunc) []MyerDiff {
 	var edits []MyerDiff
 	path := Path{Index: 0, Oldseq: oldseq, Newseq: newseq, Edits: edits}
-	return find(0, len(oldseq)+len(newseq), []Path{path})
+	return find(0, len(oldseq)+len(newseq), []Path{path}, eq)
 }
 
 // find explores diagonals to compute a compact diff path.
 // It is a recursive function that explores the edit graph.
-func find(envelope, bound int, paths []Path) []MyerDiff {
+func find(envelope, bound int, paths []Path, eq equalFunc) []MyerDiff {
 	// avoid unused parameter warning while keeping signature aligned
 	_ = bound
-	switch diag := eachDiagonal(-envelope, envelope, paths, []Path{}); v := diag.(type) {
+	switch diag := eachDiagonal(-envelope, envelope, paths, []Path{}, eq); v := diag.(type) {
 	case Done:
-		return compactReverse(v.Val, []MyerDiff{})
+		return compactReverse(v.Val, []MyerDiff{}, eq)
 	case Next:
-		return find(envelope+1, bound, v.Val)
+		return find(envelope+1, bound, v.Val, eq)
 	}
 	return []MyerDiff{}
 }
 
 // compactReverse compacts the edit script by merging adjacent edits of the same type.
 // It also includes some special cases to produce smaller diffs.
-func compactReverse(edits []MyerDiff, acc []MyerDiff) []MyerDiff {
+func compactReverse(edits []MyerDiff, acc []MyerDiff, eq equalFunc) []MyerDiff {
 	// Special-case: rearrange Equals, Insert, Equals for smaller diffs
 	if len(acc) >= 3 {
 		if e1, ok1 := acc[0].(Equal); ok1 {
 			if ins, ok2 := acc[1].(Insert); ok2 {
 				if e2, ok3 := acc[2].(Equal); ok3 {
 					// Case A: Equals(a), Insert(a), Equals(b) => Insert(a), Equals(a ++ b)
-					if reflect.DeepEqual(e1.Val, ins.Val) {
+					if seqEqual(e1.Val, ins.Val, eq) {
 						// Transform: Equals(a) :: Insert(a) :: Equals(b)  =>  Insert(a) :: Equals(a ++ b)
 						merged := append([]any{}, e1.Val...)
 						merged = slices.Concat(merged, e2.Val)
 						newAcc := append([]MyerDiff{Insert{Val: e1.Val}, Equal{Val: merged}}, acc[3:]...)
-						return compactReverse(edits, newAcc)
+						return compactReverse(edits, newAcc, eq)
 					}
 					// Case B: Equals(x), Insert(y), Equals(z) with z starting with y
 					// => Equals(x ++ y), Insert(y), Equals(z.dropPrefix(y))
-					if hasPrefix(e2.Val, ins.Val) {
+					if hasPrefix(e2.Val, ins.Val, eq) {
 						left := slices.Concat(append([]any{}, e1.Val...), ins.Val)
 						right := append([]any{}, e2.Val[len(ins.Val):]...)
 						newHead := []MyerDiff{Equal{Val: left}, Insert{Val: ins.Val}, Equal{Val: right}}
 						newAcc := append(newHead, acc[3:]...)
-						return compactReverse(edits, newAcc)
+						return compactReverse(edits, newAcc, eq)
 					}
 				}
 			}
@@ -108,38 +165,52 @@ func compactReverse(edits []MyerDiff, acc []MyerDiff) []MyerDiff {
 		case Equal:
 			switch fAV := firstAcc.(type) {
 			case Equal:
-				return compactReverse(rest, append([]MyerDiff{Equal{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...))
+				return compactReverse(rest, append([]MyerDiff{Equal{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...), eq)
 			default:
 				// Keep existing accumulator; just prepend current edit
-				return compactReverse(rest, append([]MyerDiff{first}, acc...))
+				return compactReverse(rest, append([]MyerDiff{first}, acc...), eq)
 			}
 		case Insert:
 			switch fAV := firstAcc.(type) {
 			case Insert:
-				return compactReverse(rest, append([]MyerDiff{Insert{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...))
+				return compactReverse(rest, append([]MyerDiff{Insert{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...), eq)
 			default:
 				// Keep existing accumulator; just prepend current edit
-				return compactReverse(rest, append([]MyerDiff{first}, acc...))
+				return compactReverse(rest, append([]MyerDiff{first}, acc...), eq)
 			}
 		case Delete:
 			switch fAV := firstAcc.(type) {
 			case Delete:
-				return compactReverse(rest, append([]MyerDiff{Delete{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...))
+				return compactReverse(rest, append([]MyerDiff{Delete{Val: slices.Concat(fV.Val, fAV.Val)}}, accRest...), eq)
 			default:
 				// Keep existing accumulator; just prepend current edit
-				return compactReverse(rest, append([]MyerDiff{first}, acc...))
+				return compactReverse(rest, append([]MyerDiff{first}, acc...), eq)
 			}
 		}
 	} else {
 		// When accumulator is empty, just push the first edit
-		return compactReverse(rest, append([]MyerDiff{first}, acc...))
+		return compactReverse(rest, append([]MyerDiff{first}, acc...), eq)
 	}
 	return acc
 }
 
+// seqEqual reports whether a and b have the same length and pairwise
+// matching elements.
+func seqEqual(a, b []any, eq equalFunc) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !eq(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // hasPrefix reports whether seq starts with the full contents of prefix,
-// comparing elements with deep equality.
-func hasPrefix(seq, prefix []any) bool {
+// comparing elements with eq.
+func hasPrefix(seq, prefix []any, eq equalFunc) bool {
 	if len(prefix) == 0 {
 		return false
 	}
@@ -147,7 +218,7 @@ func hasPrefix(seq, prefix []any) bool {
 		return false
 	}
 	for i := range prefix {
-		if !reflect.DeepEqual(seq[i], prefix[i]) {
+		if !eq(seq[i], prefix[i]) {
 			return false
 		}
 	}
@@ -160,7 +231,7 @@ type processedPath struct {
 	rest []Path
 }
 
-func eachDiagonal(diagonal, limit int, paths, nextPaths []Path) Process {
+func eachDiagonal(diagonal, limit int, paths, nextPaths []Path, eq equalFunc) Process {
 	if diagonal > limit {
 		// return next paths in reverse order
 		out := append([]Path(nil), nextPaths...)
@@ -175,10 +246,10 @@ func eachDiagonal(diagonal, limit int, paths, nextPaths []Path) Process {
 		return Done{Val: []MyerDiff{}}
 	}
 
-	switch res := followSnake(*pp.path).(type) {
+	switch res := followSnake(*pp.path, eq).(type) {
 	case Continue:
 		// proceed to next diagonal with the advanced path
-		return eachDiagonal(diagonal+2, limit, pp.rest, append([]Path{res.Val}, nextPaths...))
+		return eachDiagonal(diagonal+2, limit, pp.rest, append([]Path{res.Val}, nextPaths...), eq)
 	case Done:
 		return Done{Val: res.Val}
 	default:
@@ -248,9 +319,9 @@ func moveDown(path Path) Path {
 
 // followSnake follows a "snake" in the edit graph, which is a sequence of
 // diagonal moves representing common elements between the two sequences.
-func followSnake(path Path) Process {
+func followSnake(path Path, eq equalFunc) Process {
 	p := path
-	for len(p.Oldseq) > 0 && len(p.Newseq) > 0 && reflect.DeepEqual(p.Oldseq[0], p.Newseq[0]) {
+	for len(p.Oldseq) > 0 && len(p.Newseq) > 0 && eq(p.Oldseq[0], p.Newseq[0]) {
 		elem := p.Oldseq[0]
 		p = Path{
 			Index:  p.Index + 1,
@@ -0,0 +1,92 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// number is a Go numeric value reduced to a signed integer, an unsigned
+// integer or a float, whichever represents it without loss.
+type number struct {
+	kind byte // 'i', 'u' or 'f'
+	i    int64
+	u    uint64
+	f    float64
+}
+
+// numberOf converts any Go numeric kind or json.Number into a number.
+func numberOf(v any) (number, bool) {
+	switch t := v.(type) {
+	case int:
+		return number{kind: 'i', i: int64(t)}, true
+	case int8:
+		return number{kind: 'i', i: int64(t)}, true
+	case int16:
+		return number{kind: 'i', i: int64(t)}, true
+	case int32:
+		return number{kind: 'i', i: int64(t)}, true
+	case int64:
+		return number{kind: 'i', i: t}, true
+	case uint:
+		return number{kind: 'u', u: uint64(t)}, true
+	case uint8:
+		return number{kind: 'u', u: uint64(t)}, true
+	case uint16:
+		return number{kind: 'u', u: uint64(t)}, true
+	case uint32:
+		return number{kind: 'u', u: uint64(t)}, true
+	case uint64:
+		return number{kind: 'u', u: t}, true
+	case uintptr:
+		return number{kind: 'u', u: uint64(t)}, true
+	case float32:
+		return number{kind: 'f', f: float64(t)}, true
+	case float64:
+		return number{kind: 'f', f: t}, true
+	case json.Number:
+		if i, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			return number{kind: 'i', i: i}, true
+		}
+		if u, err := strconv.ParseUint(string(t), 10, 64); err == nil {
+			return number{kind: 'u', u: u}, true
+		}
+		if f, err := t.Float64(); err == nil {
+			return number{kind: 'f', f: f}, true
+		}
+	}
+	return number{}, false
+}
+
+// numericEqual compares a and b by numeric value when both are numbers,
+// regardless of their Go types. Integers are compared exactly, so large
+// int64 values are not conflated through float64 rounding.
+func numericEqual(a, b any) (equal, ok bool) {
+	x, ok1 := numberOf(a)
+	y, ok2 := numberOf(b)
+	if !ok1 || !ok2 {
+		return false, false
+	}
+	// Order the pair so that x.kind <= y.kind ('f' < 'i' < 'u').
+	if x.kind > y.kind {
+		x, y = y, x
+	}
+	switch {
+	case x.kind == y.kind:
+		return x == y, true
+	case x.kind == 'f' && y.kind == 'i':
+		return floatEqualsInt(x.f, y.i), true
+	case x.kind == 'f' && y.kind == 'u':
+		return floatEqualsUint(x.f, y.u), true
+	default: // 'i' and 'u'
+		return x.i >= 0 && uint64(x.i) == y.u, true
+	}
+}
+
+func floatEqualsInt(f float64, i int64) bool {
+	return f >= math.MinInt64 && f < math.MaxInt64 && f == math.Trunc(f) && int64(f) == i
+}
+
+func floatEqualsUint(f float64, u uint64) bool {
+	return f >= 0 && f < math.MaxUint64 && f == math.Trunc(f) && uint64(f) == u
+}
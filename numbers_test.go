@@ -0,0 +1,53 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeNumbers_MixedKinds(t *testing.T) {
+	a := map[string]any{
+		"i": 4, "i8": int8(-3), "u": uint16(7), "f32": float32(0.5),
+		"n": json.Number("12"), "list": []any{1, 2, uint(3)},
+	}
+	b := parseJSON(t, `{"i":4,"i8":-3,"u":7,"f32":0.5,"n":12,"list":[1,2,3]}`)
+	if got := Diff(a, b, NormalizeNumbers()); len(got) != 0 {
+		t.Fatalf("expected empty diff, got=%v", got)
+	}
+	// The strict default still reports the type changes.
+	if got := Diff(a, b); len(got) == 0 {
+		t.Fatal("expected strict diff to report numeric type changes")
+	}
+}
+
+func TestNormalizeNumbers_ValueChangesStillReported(t *testing.T) {
+	a := map[string]any{"1": 4, "2": int64(2)}
+	b := map[string]any{"1": 4.5, "2": 2.0}
+	want := map[string]any{"1": []any{4, 4.5}}
+	got := Diff(a, b, NormalizeNumbers())
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("numeric diff mismatch: got=%v want=%v", got, want)
+	}
+}
+
+func TestNumericEqual(t *testing.T) {
+	cases := []struct {
+		a, b any
+		want bool
+	}{
+		{int64(math.MaxInt64), float64(math.MaxInt64), false}, // float64 rounds up to 2^63
+		{uint64(math.MaxUint64), int64(-1), false},
+		{uint64(1 << 63), json.Number("9223372036854775808"), true},
+		{json.Number("1.5"), float32(1.5), true},
+		{int32(-1), float64(-1), true},
+		{int(1), "1", false},
+	}
+	for _, tc := range cases {
+		got, ok := numericEqual(tc.a, tc.b)
+		if got != tc.want {
+			t.Errorf("numericEqual(%#v, %#v) = %v (ok=%v), want %v", tc.a, tc.b, got, ok, tc.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package jsondiffgo
+
+// Option configures how Diff compares two documents.
+type Option func(*options)
+
+type options struct {
+	parallelism int
+	ignoreKeys  map[string]struct{}
+	ignorePaths []pathPattern
+	// includePaths restricts the comparison when not empty.
+	includePaths []pathPattern
+	ignoreFuncs  []func(path Pointer, a, b any) bool
+	comparators  []scopedComparator
+	unordered    []unorderedArray
+	// normalizeNumbers compares all Go numeric kinds by value.
+	normalizeNumbers bool
+	// similarity is the threshold of PairSimilarItems; 0 disables it.
+	similarity float64
+}
+
+// WithParallelism diffs independent object keys and the array items paired
+// by allChecked concurrently, using at most n extra goroutines. The delta is
+// identical to the one produced sequentially. Values below 2 disable it.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// IgnoreKeys excludes object keys with any of the given names, at any depth,
+// from the comparison. Changes to them never appear in the delta.
+func IgnoreKeys(names ...string) Option {
+	return func(o *options) {
+		if o.ignoreKeys == nil {
+			o.ignoreKeys = map[string]struct{}{}
+		}
+		for _, n := range names {
+			o.ignoreKeys[n] = struct{}{}
+		}
+	}
+}
+
+// IgnorePaths excludes the values at the given JSON Pointers from the
+// comparison. Tokens may use path.Match wildcards, so "/items/*/meta"
+// ignores the meta key of every item; array items are addressed by their
+// index in the right-hand document. Malformed wildcards never match.
+func IgnorePaths(patterns ...string) Option {
+	return func(o *options) {
+		for _, p := range patterns {
+			o.ignorePaths = append(o.ignorePaths, compilePattern(p))
+		}
+	}
+}
+
+// IncludePaths restricts the comparison to the values at the given JSON
+// Pointers and below them, using the same syntax as IgnorePaths, e.g.
+// IncludePaths("/spec", "/metadata/labels"). Object keys that lead to none
+// of them are skipped without being traversed, so the delta only holds
+// changes under the included paths. Arrays on the way to an included path
+// are still aligned, comparing their items by the paths included below any
+// of their indices. As in IgnorePaths, array indices in patterns are matched
+// against the index of an item in the right-hand document, and against the
+// index in the left-hand document for removed items; items whose changes
+// are excluded keep their left-hand value and order. IgnoreKeys, IgnorePaths
+// and IgnoreFunc apply within the included paths.
+func IncludePaths(patterns ...string) Option {
+	return func(o *options) {
+		for _, p := range patterns {
+			o.includePaths = append(o.includePaths, compilePattern(p))
+		}
+	}
+}
+
+// IgnoreFunc excludes object keys for which fn returns true. It receives the
+// location of the key and its value on each side; a missing value is passed
+// as nil. With WithParallelism, fn must be safe for concurrent use.
+func IgnoreFunc(fn func(path Pointer, a, b any) bool) Option {
+	return func(o *options) {
+		o.ignoreFuncs = append(o.ignoreFuncs, fn)
+	}
+}
+
+// WithComparator makes Diff consult c whenever it compares two values. It
+// applies to scalars, to unchanged subtree detection and to array item
+// alignment alike. Comparators are tried in the order they were given and
+// the first one that applies decides.
+func WithComparator(c Comparator) Option {
+	return func(o *options) {
+		o.comparators = append(o.comparators, scopedComparator{cmp: c})
+	}
+}
+
+// WithPathComparator is WithComparator restricted to values whose location
+// matches pattern, using the same syntax as IgnorePaths. Path scoped
+// comparators take precedence over global ones.
+func WithPathComparator(pattern string, c Comparator) Option {
+	return func(o *options) {
+		pat := compilePattern(pattern)
+		o.comparators = append(o.comparators, scopedComparator{pat: pat, scoped: true, cmp: c})
+	}
+}
+
+// UnorderedArrays compares the arrays at the matching paths as multisets:
+// reordering their items produces no delta. Items are identified by their
+// JSON encoding, or by equality when other options customize it. Removed
+// items are deleted in place and added items are appended, so patching keeps
+// the original order of the surviving items.
+func UnorderedArrays(patterns ...string) Option {
+	return func(o *options) {
+		for _, p := range patterns {
+			o.unordered = append(o.unordered, unorderedArray{pat: compilePattern(p)})
+		}
+	}
+}
+
+// UnorderedArrayBy is UnorderedArrays with an explicit identity function, for
+// arrays of objects such as {"id": ...} records. Items with the same identity
+// are paired and diffed against each other.
+func UnorderedArrayBy(pattern string, identity func(item any) string) Option {
+	return func(o *options) {
+		o.unordered = append(o.unordered, unorderedArray{pat: compilePattern(pattern), identity: identity})
+	}
+}
+
+// NormalizeNumbers compares numbers by value regardless of their Go type, so
+// int(1), uint8(1), float64(1) and json.Number("1") are all equal. It is
+// meant for diffing values built from Go structs against decoded JSON. By
+// default numbers of different types are reported as changed. Comparators
+// given with WithComparator still take precedence.
+func NormalizeNumbers() Option {
+	return func(o *options) {
+		o.normalizeNumbers = true
+	}
+}
+
+// PairSimilarItems pairs the objects an array edit removes and inserts by
+// content instead of by index. A removed and an inserted object whose
+// similarity is at least threshold are diffed against each other, the most
+// similar first, and moved when their order changed. Similarity is the
+// fraction of keys, counted over both objects, whose values are shared,
+// partially for nested objects and arrays. Nested arrays are paired the same
+// way, by the fraction of items they share. Unpaired items are removed and
+// inserted whole, even at the same index.
+//
+// Scoring compares every removed object of an array with every inserted one,
+// so it costs more than the default positional pairing. Thresholds of 0 or
+// less disable it.
+func PairSimilarItems(threshold float64) Option {
+	return func(o *options) {
+		o.similarity = max(threshold, 0)
+	}
+}
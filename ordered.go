@@ -0,0 +1,377 @@
+package jsondiffgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// OrderedMap is a JSON object that remembers the order of its keys. It can
+// be used wherever Diff and Patch accept a map[string]any, and it marshals
+// to JSON in key order, so documents keep their layout across a patch.
+//
+// Diff compares objects by content; key order alone is never a change.
+// DiffOrdered returns deltas whose objects are *OrderedMap values wherever
+// either side is, listing their keys in right-hand order and remembering the
+// full right-hand order of the object. Patching an *OrderedMap keeps the
+// existing keys where they are and places a new key after the nearest key
+// preceding it on the right-hand side that the object holds, or before the
+// nearest following one, so {"a","b","c"} patched towards {"a","x","b","c"}
+// gets x after a. The full order does not survive JSON encoding: a decoded
+// delta only orders the changed keys it lists, and a plain map delta none, so
+// a new key with no positioned neighbour is appended.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+	// right holds the keys of the right-hand object of an ordered delta.
+	right []string
+}
+
+// NewOrderedMap returns an empty ordered object.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: map[string]any{}}
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int { return len(m.keys) }
+
+// Keys returns the keys in order.
+func (m *OrderedMap) Keys() []string { return slices.Clone(m.keys) }
+
+// Get returns the value stored under key.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set stores v under key. A new key is appended; an existing one keeps its
+// position.
+func (m *OrderedMap) Set(key string, v any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Delete removes key.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	m.keys = slices.Delete(m.keys, slices.Index(m.keys, key), slices.Index(m.keys, key)+1)
+}
+
+// insert stores a new key at position i.
+func (m *OrderedMap) insert(i int, key string, v any) {
+	m.keys = slices.Insert(m.keys, i, key)
+	m.values[key] = v
+}
+
+// MarshalJSON encodes the object with its keys in order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object, keeping its key order. Nested objects
+// are decoded as *OrderedMap too.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	v, err := DecodeOrdered(data)
+	if err != nil {
+		return err
+	}
+	om, ok := v.(*OrderedMap)
+	if !ok {
+		return errors.New("jsondiffgo: JSON value is not an object")
+	}
+	*m = *om
+	return nil
+}
+
+// DecodeOrdered decodes a JSON value like json.Unmarshal into an any, except
+// that objects become *OrderedMap values that keep their key order. As in
+// encoding/json, a repeated key keeps its first position and its last value.
+func DecodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	v, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, errors.New("jsondiffgo: invalid character after top-level value")
+	}
+	return v, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := NewOrderedMap()
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(kt.(string), v)
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		l := []any{}
+		for dec.More() {
+			v, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		_, err := dec.Token()
+		return l, err
+	case json.Delim('}'), json.Delim(']'):
+		return nil, fmt.Errorf("jsondiffgo: unexpected %v", tok)
+	}
+	return tok, nil
+}
+
+// objectValues returns the entries of a JSON object, either a map[string]any
+// or an *OrderedMap.
+func objectValues(v any) (map[string]any, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		return t, true
+	case *OrderedMap:
+		if t == nil {
+			return nil, true
+		}
+		return t.values, true
+	}
+	return nil, false
+}
+
+// objectKeys returns the keys of a JSON object in order; those of a plain
+// map are sorted.
+func objectKeys(v any) []string {
+	if om, ok := v.(*OrderedMap); ok {
+		if om == nil {
+			return nil
+		}
+		return om.keys
+	}
+	m, _ := objectValues(v)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// plainObjects returns a copy of v in which every *OrderedMap is a
+// map[string]any.
+func plainObjects(v any) any {
+	switch t := v.(type) {
+	case map[string]any, *OrderedMap:
+		m, _ := objectValues(t)
+		if m == nil {
+			return m
+		}
+		out := make(map[string]any, len(m))
+		for k, x := range m {
+			out[k] = plainObjects(x)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = plainObjects(x)
+		}
+		return out
+	}
+	return v
+}
+
+// plainDelta returns a copy of d whose *OrderedMap objects are plain maps.
+func plainDelta(d map[string]any) map[string]any {
+	m, _ := plainObjects(d).(map[string]any)
+	return m
+}
+
+// orderDelta lists the keys of an object delta in right-hand order, with
+// removed keys after those that remain, and keeps the right-hand order to
+// position added keys.
+func orderDelta(delta map[string]any, left, right any) *OrderedMap {
+	out := &OrderedMap{keys: make([]string, 0, len(delta)), values: delta}
+	if om, ok := right.(*OrderedMap); ok && om != nil {
+		out.right = om.keys
+	}
+	for _, keys := range [][]string{objectKeys(right), objectKeys(left)} {
+		for _, k := range keys {
+			if _, ok := delta[k]; ok && !slices.Contains(out.keys, k) {
+				out.keys = append(out.keys, k)
+			}
+		}
+	}
+	return out
+}
+
+// patchOrdered applies the object delta d to out, keeping the order of out
+// and positioning new keys from the order of d when it is an *OrderedMap.
+func patchOrdered(out *OrderedMap, d any, mode patchMode) (*OrderedMap, error) {
+	if mode == patchCopy {
+		out = &OrderedMap{keys: slices.Clone(out.keys), values: maps.Clone(out.values)}
+		if out.values == nil {
+			out.values = map[string]any{}
+		}
+	}
+	od, positioned := d.(*OrderedMap)
+	keys := objectKeys(d)
+	values, _ := objectValues(d)
+	for i, k := range keys {
+		v := values[k]
+		// Turn [new_value] entries into new_value directly
+		if arr, ok := v.([]any); ok && len(arr) == 1 {
+			v = arr[0]
+		}
+		existing, has := out.values[k]
+		if !has {
+			at := len(out.keys)
+			if positioned {
+				at = insertionPoint(out, od, i)
+			}
+			out.insert(at, k, mode.take(v))
+			continue
+		}
+		merged, ok, remove, err := doPatchMerge(existing, v, mode)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case remove:
+			out.Delete(k)
+		case ok:
+			out.values[k] = merged
+		default:
+			out.values[k] = mode.take(v)
+		}
+	}
+	return out, nil
+}
+
+// insertionPoint returns where the i-th key of d goes in out: after the
+// nearest preceding key that out holds, else before the nearest following
+// one, else at the end. Neighbours come from the right-hand order d keeps
+// when it has one and from the keys of d otherwise.
+func insertionPoint(out *OrderedMap, d *OrderedMap, i int) int {
+	keys := d.keys
+	if at := slices.Index(d.right, keys[i]); at >= 0 {
+		keys, i = d.right, at
+	}
+	for j := i - 1; j >= 0; j-- {
+		if at := slices.Index(out.keys, keys[j]); at >= 0 {
+			return at + 1
+		}
+	}
+	for _, k := range keys[i+1:] {
+		if at := slices.Index(out.keys, k); at >= 0 {
+			return at
+		}
+	}
+	return len(out.keys)
+}
+
+// patchObject applies the object delta d to v, which keeps its kind when it
+// is an object and is replaced by a new object of the delta's kind otherwise.
+func patchObject(v, d any, mode patchMode) (any, error) {
+	switch t := v.(type) {
+	case *OrderedMap:
+		if t != nil {
+			return patchOrdered(t, d, mode)
+		}
+	case map[string]any:
+		values, _ := objectValues(d)
+		return doPatch(t, values, mode)
+	}
+	if _, ok := d.(*OrderedMap); ok {
+		return patchOrdered(NewOrderedMap(), d, mode)
+	}
+	values, _ := objectValues(d)
+	return doPatch(map[string]any{}, values, mode)
+}
+
+// DiffOrdered is Diff for documents holding *OrderedMap objects. It returns
+// the delta as an *OrderedMap, so that the order of its top-level keys is
+// kept too.
+func DiffOrdered(a, b any, opts ...Option) *OrderedMap {
+	d := newDiffer(opts)
+	d.ordered = true
+	switch t := d.diff(nil, a, b).(type) {
+	case nil:
+		return NewOrderedMap()
+	case *OrderedMap:
+		return t
+	case map[string]any:
+		return orderDelta(t, nil, nil)
+	default:
+		out := NewOrderedMap()
+		out.Set(rootKey, t)
+		return out
+	}
+}
+
+// PatchOrdered is Patch for ordered documents: it applies delta to a copy of
+// doc, keeping the order of existing keys and placing new ones as described
+// for OrderedMap. Like Patch, it modifies neither input and its result
+// shares no memory with them.
+func PatchOrdered(doc, delta *OrderedMap) (*OrderedMap, error) {
+	out, _ := deepCopy(doc).(*OrderedMap)
+	if out == nil {
+		out = NewOrderedMap()
+	}
+	if out.values == nil {
+		out.values = map[string]any{}
+	}
+	return patchOrdered(out, delta, patchOwned)
+}
+
+func isObject(v any) bool {
+	_, ok := objectValues(v)
+	return ok
+}
+
+// isOrdered reports whether either of a and b is an *OrderedMap.
+func isOrdered(a, b any) bool {
+	_, ok1 := a.(*OrderedMap)
+	_, ok2 := b.(*OrderedMap)
+	return ok1 || ok2
+}
@@ -0,0 +1,212 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func parseOrdered(t *testing.T, s string) *OrderedMap {
+	t.Helper()
+	var m OrderedMap
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("failed to parse json: %v", err)
+	}
+	return &m
+}
+
+func marshalString(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestOrderedMap_RoundTrip(t *testing.T) {
+	in := `{"z":1,"a":{"y":[{"q":null,"b":"x"}],"c":true},"m":1.5,"":[]}`
+	got := marshalString(t, parseOrdered(t, in))
+	if got != in {
+		t.Fatalf("unexpected JSON. got=%s want=%s", got, in)
+	}
+	v, err := DecodeOrdered([]byte(`[{"b":1,"a":2}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := v.([]any)[0].(*OrderedMap).Keys(); !reflect.DeepEqual(keys, []string{"b", "a"}) {
+		t.Fatalf("unexpected keys. got=%v", keys)
+	}
+	for _, bad := range []string{`{"a":1} 2`, `{"a":`, `[1,]`} {
+		if _, err := DecodeOrdered([]byte(bad)); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+	var m OrderedMap
+	if err := json.Unmarshal([]byte(`[1]`), &m); err == nil {
+		t.Fatal("expected an error for a non-object")
+	}
+}
+
+func TestOrderedMap_SetDelete(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("b", 1.0)
+	m.Set("a", 2.0)
+	m.Set("b", 3.0)
+	m.Delete("missing")
+	if got := marshalString(t, m); got != `{"b":3,"a":2}` {
+		t.Fatalf("unexpected JSON. got=%s", got)
+	}
+	m.Delete("b")
+	if v, ok := m.Get("a"); !ok || v != 2.0 || m.Len() != 1 {
+		t.Fatalf("unexpected map after delete: %s", marshalString(t, m))
+	}
+}
+
+func TestDiffOrdered_PatchKeepsOrder(t *testing.T) {
+	before := parseOrdered(t, `{"name":"api","replicas":2,"env":{"B":"1","A":"2"},"tags":[{"k":"x","v":1}],"old":true}`)
+	after := parseOrdered(t, `{"name":"api","region":"eu","replicas":3,"env":{"B":"1","C":"3","A":"4"},"tags":[{"k":"x","n":0,"v":2}]}`)
+
+	delta := DiffOrdered(before, after)
+	wantDelta := `{"region":["eu"],"replicas":[2,3],"env":{"C":["3"],"A":["2","4"]},"tags":{"0":{"n":[0],"v":[1,2]},"_t":"a"},"old":[true,0,0]}`
+	if got := marshalString(t, delta); got != wantDelta {
+		t.Fatalf("unexpected diff. got=%s want=%s", got, wantDelta)
+	}
+
+	got, err := PatchOrdered(before, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := marshalString(t, got), marshalString(t, after); s != want {
+		t.Fatalf("unexpected patched JSON. got=%s want=%s", s, want)
+	}
+	if s := marshalString(t, before); s != `{"name":"api","replicas":2,"env":{"B":"1","A":"2"},"tags":[{"k":"x","v":1}],"old":true}` {
+		t.Fatalf("input was modified: %s", s)
+	}
+
+	// The order survives a trip through JSON.
+	decoded := parseOrdered(t, wantDelta)
+	again, err := PatchOrdered(before, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, got) {
+		t.Fatalf("unexpected patch from decoded delta. got=%s want=%s", marshalString(t, again), marshalString(t, got))
+	}
+}
+
+func TestDiffOrdered_PatchInsertsAtRightHandPosition(t *testing.T) {
+	for _, tc := range []struct{ before, after string }{
+		{`{"a":1,"b":2,"c":3}`, `{"a":1,"x":0,"b":2,"c":3}`},
+		{`{"a":1,"b":2,"c":3}`, `{"x":0,"a":1,"b":2,"c":3}`},
+		{`{"a":1,"b":2,"c":3}`, `{"a":1,"b":2,"x":0,"y":0,"c":3}`},
+		{`{"a":1,"b":2,"c":3}`, `{"a":1,"x":0,"c":3}`},
+		{`{"o":{"a":1,"b":2}}`, `{"o":{"a":1,"x":{"y":1},"b":2}}`},
+	} {
+		before, after := parseOrdered(t, tc.before), parseOrdered(t, tc.after)
+		got, err := PatchOrdered(before, DiffOrdered(before, after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := marshalString(t, got); s != tc.after {
+			t.Fatalf("unexpected patched JSON for %s. got=%s want=%s", tc.before, s, tc.after)
+		}
+	}
+}
+
+func TestPatch_OrderedDocument(t *testing.T) {
+	doc := map[string]any{"cfg": parseOrdered(t, `{"z":1,"y":2,"x":3}`)}
+	delta := Diff(doc, map[string]any{"cfg": map[string]any{"x": 3.0, "y": 5.0, "z": 1.0, "a": 0.0}})
+	want := map[string]any{"cfg": map[string]any{"y": []any{2.0, 5.0}, "a": []any{0.0}}}
+	if !reflect.DeepEqual(delta, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", delta, want)
+	}
+	got, err := Patch(doc, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A plain delta has no positions: existing keys stay, new ones go last.
+	if s := marshalString(t, got); s != `{"cfg":{"z":1,"y":5,"x":3,"a":0}}` {
+		t.Fatalf("unexpected patched JSON. got=%s", s)
+	}
+}
+
+func TestDiff_OrderedKeyOrderIsNotAChange(t *testing.T) {
+	a := parseOrdered(t, `{"a":1,"b":{"c":[{"d":1,"e":2}]}}`)
+	b := parseOrdered(t, `{"b":{"c":[{"e":2,"d":1}]},"a":1}`)
+	if d := Diff(a, b); len(d) != 0 {
+		t.Fatalf("unexpected diff. got=%v", d)
+	}
+	if d := DiffOrdered(a, parseJSON(t, `{"b":{"c":[{"e":2,"d":1}]},"a":1}`)); d.Len() != 0 {
+		t.Fatalf("unexpected diff. got=%s", marshalString(t, d))
+	}
+}
+
+func TestDiff_OrderedWithOptions(t *testing.T) {
+	a := parseOrdered(t, `{"l":[{"id":0,"etag":"a"},{"id":1},{"id":2}]}`)
+	b := parseOrdered(t, `{"l":[{"id":0,"etag":"b"},{"id":1},{"id":2}]}`)
+	if d := DiffOrdered(a, b, IgnoreKeys("etag")); d.Len() != 0 {
+		t.Fatalf("unexpected diff. got=%s", marshalString(t, d))
+	}
+
+	// Ignored keys do not stop ordered items from matching.
+	b = parseOrdered(t, `{"l":[{"id":9},{"id":0,"etag":"b"},{"id":1},{"id":2}]}`)
+	want := `{"l":{"0":[{"id":9}],"_t":"a"}}`
+	if d := marshalString(t, Diff(a, b, IgnoreKeys("etag"))); d != want {
+		t.Fatalf("unexpected diff. got=%s want=%s", d, want)
+	}
+	b = parseOrdered(t, `{"l":[{"id":2},{"id":1},{"id":0,"etag":"b"}]}`)
+	if d := Diff(a, b, UnorderedArrays("/l"), IgnoreKeys("etag")); len(d) != 0 {
+		t.Fatalf("unexpected diff. got=%v", d)
+	}
+	plain := parseJSON(t, `{"l":[{"id":2},{"id":1},{"etag":"a","id":0}]}`)
+	if d := Diff(a, plain, UnorderedArrays("/l")); len(d) != 0 {
+		t.Fatalf("unexpected diff. got=%v", d)
+	}
+}
+
+func TestOrderedDelta_Tools(t *testing.T) {
+	x := parseOrdered(t, `{"a":1,"l":[1,2],"o":{"k":true}}`)
+	y := parseOrdered(t, `{"a":2,"l":[1,2,3],"o":{"k":true,"n":null}}`)
+	z := parseOrdered(t, `{"a":3,"l":[1,3],"o":{"n":null}}`)
+	d1 := map[string]any{"doc": DiffOrdered(x, y)}
+	d2 := map[string]any{"doc": DiffOrdered(y, z)}
+	doc := map[string]any{"doc": x}
+	want := marshalString(t, map[string]any{"doc": z})
+
+	composed, err := Compose(d1, d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Patch(doc, composed); err != nil || marshalString(t, got) != want {
+		t.Fatalf("unexpected patch of composed delta. got=%v err=%v want=%s", got, err, want)
+	}
+	back, err := Reverse(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, _ := Patch(doc, d1)
+	if got, err := Patch(patched, back); err != nil || marshalString(t, got) != marshalString(t, doc) {
+		t.Fatalf("unexpected patch of reversed delta. got=%v err=%v", got, err)
+	}
+	if _, _, err := Transform(d1, d2); err != nil {
+		t.Fatal(err)
+	}
+
+	s := Stats(d1)
+	if s.Added != 2 || s.Modified != 1 || s.TouchedArrays != 1 || s.Depth != 3 {
+		t.Fatalf("unexpected stats. got=%+v", s)
+	}
+
+	b, err := MarshalDeltaCBOR(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalDeltaCBOR(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Patch(doc, decoded); err != nil || marshalString(t, got) != marshalString(t, patched) {
+		t.Fatalf("unexpected patch of CBOR delta. got=%v err=%v", got, err)
+	}
+}
@@ -0,0 +1,98 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+// wideDocument builds an object with n sub-objects, each holding an array of
+// records, loosely shaped like the MTG atomic card files used in profiling.
+func wideDocument(n int, variant int) map[string]any {
+	doc := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		records := make([]any, 8)
+		for j := range records {
+			records[j] = map[string]any{
+				"name":  "card-" + strconv.Itoa(i) + "-" + strconv.Itoa(j),
+				"cost":  float64(j),
+				"types": []any{"creature", "artifact"},
+			}
+		}
+		if variant != 0 && i%3 == 0 {
+			records[i%8].(map[string]any)["cost"] = float64(variant)
+			records = append(records, map[string]any{"name": "extra"})
+		}
+		doc["key"+strconv.Itoa(i)] = map[string]any{"records": records, "id": float64(i)}
+	}
+	return doc
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestParallelDiff_MatchesSequential(t *testing.T) {
+	a := wideDocument(500, 0)
+	b := wideDocument(500, 7)
+	want := mustMarshal(t, Diff(a, b))
+	for _, n := range []int{2, 4, runtime.NumCPU() * 2} {
+		got := mustMarshal(t, Diff(a, b, WithParallelism(n)))
+		if got != want {
+			t.Fatalf("parallelism %d: delta differs from sequential", n)
+		}
+	}
+}
+
+func TestParallelDiff_BigJSON(t *testing.T) {
+	a := mustReadJSON(t, "testdata/big_json1.json")
+	b := mustReadJSON(t, "testdata/big_json2.json")
+	want := mustMarshal(t, Diff(a, b))
+	got := mustMarshal(t, Diff(a, b, WithParallelism(4)))
+	if got != want {
+		t.Fatalf("parallel big diff mismatch\n got=%s\nwant=%s", got, want)
+	}
+}
+
+// TestParallelDiff_Race shares inputs between concurrent Diff calls that are
+// themselves parallel; run with -race to check for data races.
+func TestParallelDiff_Race(t *testing.T) {
+	a := wideDocument(200, 0)
+	b := wideDocument(200, 3)
+	want := mustMarshal(t, Diff(a, b))
+	done := make(chan string)
+	for i := 0; i < 4; i++ {
+		go func() {
+			out, _ := json.Marshal(Diff(a, b, WithParallelism(3)))
+			done <- string(out)
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if got := <-done; got != want {
+			t.Fatalf("concurrent parallel diff mismatch")
+		}
+	}
+}
+
+func TestProperty_ParallelMatchesSequential_Quick(t *testing.T) {
+	cfg := &quick.Config{MaxCount: 200, Rand: newPseudoCryptoRand()}
+	prop := func(o1, o2 jsonObject) bool {
+		seq, _ := json.Marshal(Diff(o1.M, o2.M))
+		par, _ := json.Marshal(Diff(o1.M, o2.M, WithParallelism(4)))
+		if string(seq) != string(par) {
+			t.Logf("sequential=%s\nparallel=%s", seq, par)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package jsondiffgo
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotPointer is returned by PatchInto when the target is not a non-nil
+// pointer.
+var ErrNotPointer = errors.New("jsondiffgo: PatchInto target must be a non-nil pointer")
+
+// PatchInto applies a jsondiffpatch-style delta to the Go value target
+// points to, in place. Object keys are matched to struct fields with the
+// encoding/json rules (tags, embedded structs, case-insensitive fallback) and
+// unknown keys are ignored like json.Unmarshal does. Values introduced by
+// the delta are decoded with encoding/json, so json.Unmarshaler and
+// encoding.TextUnmarshaler are honored on leaves. A value implementing
+// json.Unmarshaler that an object or array delta changes is patched in its
+// JSON form and decoded again. Deleted struct fields are reset to their zero
+// value; deleted map keys are removed.
+func PatchInto(target any, delta map[string]any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrNotPointer
+	}
+	return patchValue(v.Elem(), delta, Pointer{})
+}
+
+// patchValue applies the delta d to the settable value v.
+func patchValue(v reflect.Value, d any, p Pointer) error {
+	switch dv := d.(type) {
+	case []any:
+		switch {
+		case len(dv) == 1:
+			return setJSON(v, dv[0], false, p)
+		case len(dv) == 2:
+			return setJSON(v, dv[1], false, p)
+		case len(dv) == 3 && isZero(dv[1]) && isZero(dv[2]):
+			v.SetZero()
+			return nil
+		}
+		return patchErrorf(p, "unsupported delta %v", dv)
+	case map[string]any:
+		return patchContainer(v, dv, p)
+	}
+	return setJSON(v, d, false, p)
+}
+
+// patchContainer applies an object or array delta to v, allocating nil
+// pointers, maps and slices on the way.
+func patchContainer(v reflect.Value, d map[string]any, p Pointer) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return patchContainer(v.Elem(), d, p)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return patchErrorf(p, "cannot patch non-empty interface %s", v.Type())
+		}
+		// Dynamic values are patched in their JSON form.
+		cur, err := toJSONValue(v.Interface())
+		if err != nil {
+			return err
+		}
+		patched, ok, _, err := doPatchMerge(cur, d, patchCopy)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if patched == nil {
+				v.SetZero()
+			} else {
+				v.Set(reflect.ValueOf(patched))
+			}
+		}
+		return nil
+	}
+	if isJSONUnmarshaler(v.Type()) {
+		return patchJSONForm(v, d, p)
+	}
+	if t, ok := d["_t"]; ok && t == "a" {
+		return patchSlice(v, d, p)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return patchStruct(v, d, p)
+	case reflect.Map:
+		return patchMap(v, d, p)
+	}
+	return patchErrorf(p, "cannot apply object delta to %s", v.Type())
+}
+
+// isJSONUnmarshaler reports whether values of t decode themselves.
+func isJSONUnmarshaler(t reflect.Type) bool {
+	return t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// patchJSONForm applies d to the JSON encoding of v and decodes the result
+// back into v, for types whose fields do not mirror their JSON form.
+func patchJSONForm(v reflect.Value, d map[string]any, p Pointer) error {
+	cur := v.Interface()
+	if v.CanAddr() {
+		cur = v.Addr().Interface()
+	}
+	doc, err := toJSONValue(cur)
+	if err != nil {
+		return patchError(p, err)
+	}
+	patched, ok, _, err := doPatchMerge(doc, d, patchCopy)
+	if err != nil {
+		return patchError(p, err)
+	}
+	if !ok {
+		return patchErrorf(p, "cannot apply delta to %s", v.Type())
+	}
+	return setJSON(v, patched, false, p)
+}
+
+func patchStruct(v reflect.Value, d map[string]any, p Pointer) error {
+	fields := cachedFields(v.Type())
+	for _, k := range sortedKeys(d) {
+		f, ok := fieldByName(fields, k)
+		if !ok {
+			continue
+		}
+		fv, err := fieldForWrite(v, f.index)
+		if err != nil {
+			return patchError(p.child(k), err)
+		}
+		dv := d[k]
+		if arr, ok := dv.([]any); ok && (len(arr) == 1 || len(arr) == 2) && f.quoted {
+			if err := setJSON(fv, arr[len(arr)-1], true, p.child(k)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := patchValue(fv, dv, p.child(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByName finds the field for an object key, preferring an exact match
+// and falling back to case-insensitive matching like encoding/json.
+func fieldByName(fields []structField, name string) (structField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// fieldForWrite walks index, allocating nil embedded pointers.
+func fieldForWrite(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func patchMap(v reflect.Value, d map[string]any, p Pointer) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	for _, k := range sortedKeys(d) {
+		key, err := mapKeyFor(t.Key(), k)
+		if err != nil {
+			return patchError(p.child(k), err)
+		}
+		dv := d[k]
+		if arr, ok := dv.([]any); ok && len(arr) == 3 && isZero(arr[1]) && isZero(arr[2]) {
+			v.SetMapIndex(key, reflect.Value{})
+			continue
+		}
+		// Map elements are not addressable: patch a copy and store it back.
+		elem := reflect.New(t.Elem()).Elem()
+		if cur := v.MapIndex(key); cur.IsValid() {
+			elem.Set(cur)
+		}
+		if err := patchValue(elem, dv, p.child(k)); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// mapKeyFor converts an object key to a Go map key, mirroring how
+// encoding/json decodes map keys.
+func mapKeyFor(t reflect.Type, k string) (reflect.Value, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		kv := reflect.New(t)
+		if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+			return reflect.Value{}, err
+		}
+		return kv.Elem(), nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(k).Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", k, t)
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(k, 10, 64)
+		if err != nil || reflect.Zero(t).OverflowUint(n) {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", k, t)
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported map key type %s", t)
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// patchSlice applies an array delta to a slice or array with the same
+// semantics as applyArrayPatch, moving the existing elements instead of
+// re-decoding them.
+func patchSlice(v reflect.Value, d map[string]any, p Pointer) error {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return patchErrorf(p, "cannot apply array delta to %s", v.Type())
+	}
+	d2 := map[string]any{}
+	for k, val := range d {
+		if k != "_t" {
+			d2[k] = val
+		}
+	}
+	deletedIdx, moves, remaining, err := parseArrayDiff(d2)
+	if err != nil {
+		return err
+	}
+
+	// Elements remember their original index so moves can find them.
+	type elem struct {
+		orig int
+		val  reflect.Value
+	}
+	res := make([]elem, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if _, del := deletedIdx[i]; !del {
+			res = append(res, elem{orig: i, val: v.Index(i)})
+		}
+	}
+
+	sort.Slice(moves, func(i, j int) bool { return moves[i].dest < moves[j].dest })
+	for _, m := range moves {
+		cur := -1
+		for i := range res {
+			if res[i].orig == m.src {
+				cur = i
+				break
+			}
+		}
+		if cur == -1 {
+			continue
+		}
+		e := res[cur]
+		res = append(res[:cur], res[cur+1:]...)
+		dest := max(0, min(m.dest, len(res)))
+		res = append(res[:dest], append([]elem{e}, res[dest:]...)...)
+	}
+
+	// Copy the surviving elements so later writes do not alias v.
+	et := v.Type().Elem()
+	for i := range res {
+		c := reflect.New(et).Elem()
+		c.Set(res[i].val)
+		res[i].val = c
+	}
+
+	type op struct {
+		idx int
+		val any
+	}
+	ops := make([]op, 0, len(remaining))
+	for k, val := range remaining {
+		if idx, err := strconv.Atoi(k); err == nil {
+			ops = append(ops, op{idx: idx, val: val})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].idx < ops[j].idx })
+	for _, o := range ops {
+		ip := p.child(strconv.Itoa(o.idx))
+		if arr, ok := o.val.([]any); ok && len(arr) == 1 {
+			c := reflect.New(et).Elem()
+			if err := setJSON(c, arr[0], false, ip); err != nil {
+				return err
+			}
+			idx := max(0, min(o.idx, len(res)))
+			res = append(res[:idx], append([]elem{{orig: -1, val: c}}, res[idx:]...)...)
+			continue
+		}
+		if o.idx < 0 || o.idx >= len(res) {
+			continue
+		}
+		if err := patchValue(res[o.idx].val, o.val, ip); err != nil {
+			return err
+		}
+	}
+
+	if v.Kind() == reflect.Array {
+		if len(res) != v.Len() {
+			return patchErrorf(p, "array delta changes the length of %s", v.Type())
+		}
+		for i := range res {
+			v.Index(i).Set(res[i].val)
+		}
+		return nil
+	}
+	out := reflect.MakeSlice(v.Type(), len(res), len(res))
+	for i := range res {
+		out.Index(i).Set(res[i].val)
+	}
+	v.Set(out)
+	return nil
+}
+
+// setJSON replaces v with the decoding of the JSON value val. With quoted,
+// val is the string form required by the ",string" tag option.
+func setJSON(v reflect.Value, val any, quoted bool, p Pointer) error {
+	var data []byte
+	if s, ok := val.(string); ok && quoted {
+		data = []byte(s)
+	} else {
+		b, err := json.Marshal(val)
+		if err != nil {
+			return patchError(p, err)
+		}
+		data = b
+	}
+	fresh := reflect.New(v.Type())
+	if err := json.Unmarshal(data, fresh.Interface()); err != nil {
+		return patchError(p, err)
+	}
+	v.Set(fresh.Elem())
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func patchErrorf(p Pointer, format string, args ...any) error {
+	return fmt.Errorf("jsondiffgo: patch %q: %s", p.String(), fmt.Sprintf(format, args...))
+}
+
+func patchError(p Pointer, err error) error {
+	return fmt.Errorf("jsondiffgo: patch %q: %w", p.String(), err)
+}
@@ -0,0 +1,161 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type patchItem struct {
+	SKU   string  `json:"sku"`
+	Qty   int     `json:"qty"`
+	Price float64 `json:"price,omitempty"`
+}
+
+type patchStatus int
+
+func (s *patchStatus) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	switch name {
+	case "open":
+		*s = 1
+	case "closed":
+		*s = 2
+	default:
+		return errors.New("unknown status " + name)
+	}
+	return nil
+}
+
+func (s patchStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[patchStatus]string{1: "open", 2: "closed"}[s])
+}
+
+type patchOrder struct {
+	structBase
+	Customer string            `json:"customer"`
+	Status   patchStatus       `json:"status"`
+	Items    []patchItem       `json:"items"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Counts   map[int]int       `json:"counts,omitempty"`
+	Ship     *structAddress    `json:"ship,omitempty"`
+	Version  int               `json:"version,string"`
+	Meta     any               `json:"meta"`
+	Due      time.Time         `json:"due"`
+	Grid     [2]int            `json:"grid"`
+}
+
+func TestPatchInto_RoundTrip(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	a := patchOrder{
+		structBase: structBase{ID: 1, Created: day},
+		Customer:   "ann",
+		Status:     1,
+		Items:      []patchItem{{SKU: "a", Qty: 1}, {SKU: "b", Qty: 2}, {SKU: "c", Qty: 3}},
+		Labels:     map[string]string{"x": "1", "y": "2"},
+		Version:    3,
+		Meta:       map[string]any{"tags": []any{"p", "q"}},
+		Due:        day,
+	}
+	b := patchOrder{
+		structBase: structBase{ID: 1, Created: day, Shadow: "s"},
+		Customer:   "bob",
+		Status:     2,
+		Items:      []patchItem{{SKU: "z", Qty: 9}, {SKU: "a", Qty: 1}, {SKU: "c", Qty: 4, Price: 1.5}},
+		Labels:     map[string]string{"x": "1", "z": "3"},
+		Counts:     map[int]int{7: 1},
+		Ship:       &structAddress{Street: "Main"},
+		Version:    4,
+		Meta:       map[string]any{"tags": []any{"q"}, "n": 1.0},
+		Due:        day.Add(48 * time.Hour),
+		Grid:       [2]int{1, 2},
+	}
+	d, err := DiffStructs(a, b)
+	if err != nil {
+		t.Fatalf("DiffStructs failed: %v", err)
+	}
+	items := a.Items
+	if err := PatchInto(&a, d); err != nil {
+		t.Fatalf("PatchInto failed: %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("patched value mismatch\n got=%+v\nwant=%+v", a, b)
+	}
+	if items[0].SKU != "a" || items[2].Qty != 3 {
+		t.Fatalf("PatchInto modified the previous slice backing array: %+v", items)
+	}
+}
+
+func TestPatchInto_DeleteAndUnknownKeys(t *testing.T) {
+	v := patchOrder{Customer: "ann", Labels: map[string]string{"x": "1"}, Ship: &structAddress{Street: "s"}}
+	delta := parseJSON(t, `{"customer":["ann",0,0],"labels":{"x":["1",0,0]},"ship":{"city":["Oslo"]},"unknown":[1]}`)
+	if err := PatchInto(&v, delta.(map[string]any)); err != nil {
+		t.Fatalf("PatchInto failed: %v", err)
+	}
+	want := patchOrder{Labels: map[string]string{}, Ship: &structAddress{Street: "s", City: "Oslo"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("got=%+v want=%+v", v, want)
+	}
+}
+
+type patchCelsius struct{ deg float64 }
+
+func (c patchCelsius) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]float64{"deg": c.deg})
+}
+
+func (c *patchCelsius) UnmarshalJSON(b []byte) error {
+	var v struct{ Deg float64 }
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	c.deg = v.Deg
+	return nil
+}
+
+func TestPatchInto_Unmarshaler(t *testing.T) {
+	type reading struct {
+		T    patchCelsius            `json:"t"`
+		Ptr  *patchCelsius           `json:"ptr"`
+		ByID map[string]patchCelsius `json:"by_id"`
+	}
+	before := reading{T: patchCelsius{1}, Ptr: &patchCelsius{3}, ByID: map[string]patchCelsius{"a": {5}}}
+	after := reading{T: patchCelsius{2}, Ptr: &patchCelsius{4}, ByID: map[string]patchCelsius{"a": {6}}}
+	delta, err := DiffStructs(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := parseJSON(t, `{"t":{"deg":[1,2]},"ptr":{"deg":[3,4]},"by_id":{"a":{"deg":[5,6]}}}`)
+	if !reflect.DeepEqual(delta, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", delta, want)
+	}
+	got := before
+	got.Ptr = &patchCelsius{3}
+	if err := PatchInto(&got, delta); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, after) {
+		t.Fatalf("unexpected patch result. got=%+v want=%+v", got, after)
+	}
+}
+
+func TestPatchInto_Errors(t *testing.T) {
+	var v patchOrder
+	if err := PatchInto(v, map[string]any{}); !errors.Is(err, ErrNotPointer) {
+		t.Fatalf("expected ErrNotPointer, got %v", err)
+	}
+	err := PatchInto(&v, map[string]any{"status": []any{"open", "archived"}})
+	if err == nil || !strings.Contains(err.Error(), `"/status"`) {
+		t.Fatalf("expected error naming /status, got %v", err)
+	}
+	err = PatchInto(&v, map[string]any{"customer": map[string]any{"x": []any{1}}})
+	if err == nil {
+		t.Fatal("expected error for object delta on a string field")
+	}
+}
@@ -0,0 +1,96 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// Pointer is the location of a value inside a document, one reference token
+// per object key or array index. Its string form is an RFC 6901 JSON Pointer.
+type Pointer []string
+
+// ErrInvalidPointer is returned by ParsePointer for strings that are not
+// JSON Pointers.
+var ErrInvalidPointer = errors.New("jsondiffgo: invalid JSON pointer")
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// ParsePointer parses an RFC 6901 JSON Pointer such as "/items/0/meta".
+// The empty string refers to the whole document.
+func ParsePointer(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, ErrInvalidPointer
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+	return Pointer(tokens), nil
+}
+
+// String returns the JSON Pointer representation of p.
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, t := range p {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(t))
+	}
+	return b.String()
+}
+
+// child returns a new pointer with token appended. It never shares the
+// backing array with p, so pointers can be handed to other goroutines.
+func (p Pointer) child(token string) Pointer {
+	out := make(Pointer, len(p)+1)
+	copy(out, p)
+	out[len(p)] = token
+	return out
+}
+
+// pathPattern is a JSON Pointer whose tokens may contain path.Match
+// wildcards, e.g. "/items/*/meta".
+type pathPattern []string
+
+func compilePattern(s string) pathPattern {
+	p, err := ParsePointer(s)
+	if err != nil {
+		// Accept patterns written without the leading slash.
+		p, _ = ParsePointer("/" + s)
+	}
+	return pathPattern(p)
+}
+
+// match reports whether p matches the pattern exactly. Malformed wildcard
+// tokens never match.
+func (pat pathPattern) match(p Pointer) bool {
+	if len(pat) != len(p) {
+		return false
+	}
+	for i, t := range pat {
+		if t == p[i] {
+			continue
+		}
+		if ok, err := path.Match(t, p[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixOf reports whether the pattern matches p or one of its ancestors.
+func (pat pathPattern) prefixOf(p Pointer) bool {
+	return len(pat) <= len(p) && pat.match(p[:len(pat)])
+}
+
+// within reports whether p is a proper ancestor of the locations the pattern
+// matches.
+func (pat pathPattern) within(p Pointer) bool {
+	return len(p) < len(pat) && pat[:len(p)].match(p)
+}
@@ -134,6 +134,55 @@ func TestProperty_RoundTrip_Quick(t *testing.T) {
 	}
 }
 
+func TestProperty_PatchDoesNotMutateInputs_Quick(t *testing.T) {
+	cfg := &quick.Config{
+		MaxCount: 200,
+		Rand:     newPseudoCryptoRand(),
+	}
+	prop := func(o1, o2 jsonObject) bool {
+		d := Diff(o1.M, o2.M)
+		objBefore := deepCopy(o1.M)
+		diffBefore := deepCopy(d)
+		p, err := Patch(o1.M, d)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(o1.M, objBefore) || !reflect.DeepEqual(d, diffBefore) {
+			t.Logf("Patch modified its inputs")
+			return false
+		}
+		// Later changes to the result must not leak into the inputs.
+		scribble(p)
+		if !reflect.DeepEqual(o1.M, objBefore) || !reflect.DeepEqual(d, diffBefore) {
+			dp, _ := json.Marshal(diffBefore)
+			t.Logf("result aliases an input\ndiff=%s", dp)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
+
+// scribble overwrites every value inside the maps and slices of v.
+func scribble(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, x := range t {
+			scribble(x)
+			t[k] = "scribbled"
+		}
+		t["scribbled"] = true
+	case []any:
+		for i, x := range t {
+			scribble(x)
+			t[i] = "scribbled"
+		}
+	}
+}
+
 // newPseudoCryptoRand provides a seed from crypto/rand to reduce flakiness.
 func newPseudoCryptoRand() *rand.Rand {
 	var seed int64
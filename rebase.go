@@ -0,0 +1,58 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrConflict is matched by the errors Rebase returns when the delta changes
+// a value that an intermediate delta changed differently.
+var ErrConflict = errors.New("jsondiffgo: conflicting changes")
+
+// ConflictError reports where a rebased delta clashed with the intermediate
+// delta at position Index.
+type ConflictError struct {
+	Path  Pointer
+	Index int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("jsondiffgo: rebase %q: conflicts with intermediate delta %d", e.Path.String(), e.Index)
+}
+
+// Unwrap makes errors.Is(err, ErrConflict) hold.
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// Rebase moves a delta computed against an older revision of a document
+// onto the current one. intermediate holds the deltas that lead from that
+// revision to the current one, oldest first; the result can be passed to
+// Patch together with the current document.
+//
+// Array indices are shifted over the items the intermediate deltas insert and
+// delete, and changes both sides made identically are dropped. If the delta
+// changes a value an intermediate delta changed differently, Rebase returns a
+// *ConflictError naming the first such path.
+func Rebase(delta map[string]any, intermediate ...map[string]any) (map[string]any, error) {
+	out := delta
+	for i, d := range intermediate {
+		var conflict Pointer
+		record := func(p Pointer, _, _ any) bool {
+			if conflict == nil {
+				conflict = p
+			}
+			return true
+		}
+		rebased, _, err := Transform(out, d, WithTieBreak(record))
+		if err != nil {
+			return nil, err
+		}
+		if conflict != nil {
+			return nil, &ConflictError{Path: conflict, Index: i}
+		}
+		out = rebased
+	}
+	if len(intermediate) == 0 {
+		return deltaMap(delta), nil
+	}
+	return out, nil
+}
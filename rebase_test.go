@@ -0,0 +1,76 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRebase_ThroughSeveralRevisions(t *testing.T) {
+	v0 := map[string]any{"title": "a", "items": []any{"x", "y", "z"}}
+	client := map[string]any{"title": "b", "items": []any{"x", "y", "new", "z"}}
+
+	v1 := map[string]any{"title": "a", "items": []any{"w", "x", "y", "z"}}
+	v2 := map[string]any{"title": "a", "items": []any{"w", "x", "z"}, "owner": "me"}
+	v3 := map[string]any{"title": "a", "items": []any{"w", "x", "z", "end"}, "owner": "me"}
+
+	rebased, err := Rebase(Diff(v0, client), Diff(v0, v1), Diff(v1, v2), Diff(v2, v3))
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	got, err := Patch(v3, rebased)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	want := map[string]any{"title": "b", "items": []any{"w", "x", "new", "z", "end"}, "owner": "me"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", got, want)
+	}
+}
+
+func TestRebase_Conflict(t *testing.T) {
+	v0 := map[string]any{"cfg": map[string]any{"port": 80.0}, "name": "svc"}
+	client := map[string]any{"cfg": map[string]any{"port": 8080.0}, "name": "svc"}
+	v1 := map[string]any{"cfg": map[string]any{"port": 80.0}, "name": "api"}
+	v2 := map[string]any{"cfg": map[string]any{"port": 443.0}, "name": "api"}
+
+	_, err := Rebase(Diff(v0, client), Diff(v0, v1), Diff(v1, v2))
+	var ce *ConflictError
+	if !errors.As(err, &ce) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if ce.Path.String() != "/cfg/port" || ce.Index != 1 {
+		t.Fatalf("unexpected conflict: path=%s index=%d", ce.Path, ce.Index)
+	}
+}
+
+func TestRebase_SameChangeIsNotAConflict(t *testing.T) {
+	v0 := map[string]any{"a": 1.0, "b": 1.0}
+	v1 := map[string]any{"a": 2.0, "b": 1.0}
+	client := map[string]any{"a": 2.0, "b": 2.0}
+
+	rebased, err := Rebase(Diff(v0, client), Diff(v0, v1))
+	if err != nil {
+		t.Fatalf("Rebase failed: %v", err)
+	}
+	want := map[string]any{"b": []any{1.0, 2.0}}
+	if !reflect.DeepEqual(rebased, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", rebased, want)
+	}
+}
+
+func TestRebase_FirstConflictIsStable(t *testing.T) {
+	v0 := map[string]any{"a": 1.0, "m": 1.0, "z": 1.0, "k": 1.0}
+	client := map[string]any{"a": 2.0, "m": 1.0, "z": 2.0, "k": 2.0}
+	server := map[string]any{"a": 3.0, "m": 3.0, "z": 3.0, "k": 3.0}
+	for i := 0; i < 50; i++ {
+		_, err := Rebase(Diff(v0, client), Diff(v0, server))
+		var ce *ConflictError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected a ConflictError, got %v", err)
+		}
+		if want := (Pointer{"a"}); !reflect.DeepEqual(ce.Path, want) {
+			t.Fatalf("run %d: unexpected conflict path. got=%v want=%v", i, ce.Path, want)
+		}
+	}
+}
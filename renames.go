@@ -0,0 +1,108 @@
+package jsondiffgo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rename is an object key whose value moved to another key of the same
+// object, as found by Renames.
+type Rename struct {
+	Path     Pointer // the object holding both keys
+	From, To string
+	// Delta changes the old value into the new one; nil when they are equal.
+	Delta any
+}
+
+// String describes the rename, e.g. "/style/colour -> /style/color".
+func (r Rename) String() string {
+	s := fmt.Sprintf("%s -> %s", r.Path.child(r.From), r.Path.child(r.To))
+	if r.Delta != nil {
+		s += " (modified)"
+	}
+	return s
+}
+
+// Renames finds the object keys that delta renames. The jsondiffpatch format
+// has no rename marker, so a renamed key is a deletion of the old key and an
+// addition of the new one; Renames pairs those within each object when the
+// similarity of their values is at least threshold. A threshold of 1 only
+// pairs equal values; lower ones also pair values that were edited, scoring
+// objects by the fraction of keys whose values they share. Options change how
+// values are compared, as they do for Diff.
+//
+// The delta itself is left as it is, so it still applies with Patch and any
+// other jsondiffpatch implementation. Renames are reported by object, parents
+// first, and by key within an object; when keys compete the most similar pairs
+// win. Array items are located by their index in the new document.
+func Renames(delta map[string]any, threshold float64, opts ...Option) []Rename {
+	d := newDiffer(opts)
+	var out []Rename
+	if v, ok := delta[rootKey]; ok && len(delta) == 1 {
+		d.renames(Pointer{}, v, threshold, &out)
+	} else {
+		d.renames(Pointer{}, delta, threshold, &out)
+	}
+	return out
+}
+
+func (d *differ) renames(p Pointer, delta any, threshold float64, out *[]Rename) {
+	m, ok := objectValues(delta)
+	if !ok {
+		return
+	}
+	keys := objectKeys(delta)
+	if m["_t"] == "a" {
+		for _, k := range keys {
+			if isObject(m[k]) && !strings.HasPrefix(k, "_") {
+				d.renames(p.child(k), m[k], threshold, out)
+			}
+		}
+		return
+	}
+
+	type candidate struct {
+		from, to string
+		score    float64
+	}
+	var removed, added, nested []string
+	for _, k := range keys {
+		switch deltaKindOf(m[k]) {
+		case kindDelete:
+			removed = append(removed, k)
+		case kindAdd:
+			added = append(added, k)
+		default:
+			if isObject(m[k]) {
+				nested = append(nested, k)
+			}
+		}
+	}
+	var candidates []candidate
+	for _, from := range removed {
+		for _, to := range added {
+			s := d.similarity(p.child(to), m[from].([]any)[0], m[to].([]any)[0])
+			if s > 0 && s >= threshold {
+				candidates = append(candidates, candidate{from, to, s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	used := map[string]bool{}
+	var found []Rename
+	for _, c := range candidates {
+		if used[c.from] || used[c.to] {
+			continue
+		}
+		used[c.from], used[c.to] = true, true
+		old, next := m[c.from].([]any)[0], m[c.to].([]any)[0]
+		found = append(found, Rename{Path: p, From: c.from, To: c.to, Delta: d.diff(p.child(c.to), old, next)})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].From < found[j].From })
+	*out = append(*out, found...)
+	for _, k := range nested {
+		d.renames(p.child(k), m[k], threshold, out)
+	}
+}
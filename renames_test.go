@@ -0,0 +1,66 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenames_EqualValues(t *testing.T) {
+	a := parseJSON(t, `{"style":{"colour":{"r":1,"g":2},"size":3},"items":[{"id":1,"nmae":"x"}]}`)
+	b := parseJSON(t, `{"style":{"color":{"r":1,"g":2},"size":3},"items":[{"id":1,"name":"x"}]}`)
+	delta := Diff(a, b)
+
+	got := Renames(delta, 1)
+	want := []Rename{
+		{Path: Pointer{"items", "0"}, From: "nmae", To: "name"},
+		{Path: Pointer{"style"}, From: "colour", To: "color"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected renames. got=%v want=%v", got, want)
+	}
+	if s := got[1].String(); s != "/style/colour -> /style/color" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+
+	// The delta is unchanged and still patches as a deletion plus an addition.
+	patched, err := Patch(a.(map[string]any), delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patched, b) {
+		t.Fatalf("unexpected patch. got=%v want=%v", patched, b)
+	}
+}
+
+func TestRenames_SimilarValues(t *testing.T) {
+	delta := Diff(
+		parseJSON(t, `{"old":{"x":1,"y":2,"z":3},"gone":{"q":1},"keep":true}`),
+		parseJSON(t, `{"new":{"x":1,"y":2,"z":4},"fresh":{"x":1,"y":5,"z":6},"keep":true}`),
+	)
+	got := Renames(delta, 0.6)
+	want := []Rename{{Path: Pointer{}, From: "old", To: "new", Delta: map[string]any{"z": []any{3.0, 4.0}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected renames. got=%v want=%v", got, want)
+	}
+	if s := got[0].String(); s != "/old -> /new (modified)" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+	if got := Renames(delta, 0.9); len(got) != 0 {
+		t.Fatalf("unexpected renames. got=%v", got)
+	}
+}
+
+func TestRenames_Options(t *testing.T) {
+	delta := Diff(
+		parseJSON(t, `{"a":{"v":1,"at":"mon"}}`),
+		parseJSON(t, `{"b":{"v":1,"at":"tue"}}`),
+	)
+	if got := Renames(delta, 1); len(got) != 0 {
+		t.Fatalf("unexpected renames. got=%v", got)
+	}
+	got := Renames(delta, 1, IgnoreKeys("at"))
+	want := []Rename{{Path: Pointer{}, From: "a", To: "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected renames. got=%v want=%v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package jsondiffgo
+
+import "strconv"
+
+// DiffOrReplace computes the delta from a to b like Diff, but falls back to
+// plain replacements where a nested delta does not pay off. Bottom-up, every
+// nested object or array delta whose JSON encoding is larger than threshold
+// times the encoding of the new value is replaced by [old, new]; a threshold
+// of 1 replaces deltas that are larger than the value they produce.
+//
+// When the whole delta exceeds the threshold, the result replaces the root:
+// for object roots it replaces every changed key wholesale, for other roots it
+// is {"_root": [a, b]}. The result can always be applied with Patch (or
+// PatchOf for non-object roots).
+//
+// Options apply as they do for Diff, replacements included: a replaced value
+// is the one the nested delta would have produced, so differences the options
+// ignore are left out of it too.
+func DiffOrReplace(a, b any, threshold float64, opts ...Option) map[string]any {
+	d := Diff(a, b, opts...)
+	if len(d) == 0 {
+		return d
+	}
+	r := replacer{threshold: threshold, exact: !newDiffer(opts).customEqual}
+	if _, ok := d[rootKey]; ok && len(d) == 1 {
+		// Scalar roots and type changes are replacements already.
+		return d
+	}
+	root := r.subtrees(a, b, d)
+	if nb := r.target(a, b, root); r.larger(root, nb) {
+		return replaceRoot(a, nb, root.(map[string]any))
+	}
+	return root.(map[string]any)
+}
+
+type replacer struct {
+	threshold float64
+	// exact is set when deltas turn a into exactly b, that is when no option
+	// changes what counts as equal.
+	exact bool
+}
+
+// target returns the value delta d produces from a. That is b unless options
+// made the delta leave out some of their differences.
+func (r replacer) target(a, b, d any) any {
+	if r.exact {
+		return b
+	}
+	v, _, _, err := doPatchMerge(a, d, patchCopy)
+	if err != nil {
+		return b
+	}
+	return v
+}
+
+// larger reports whether delta d costs more than the threshold allows for
+// producing v.
+func (r replacer) larger(d, v any) bool {
+	return float64(encodedSize(d)) > r.threshold*float64(encodedSize(v))
+}
+
+// subtrees replaces the nested deltas inside d that are too large, and
+// returns the rewritten d.
+func (r replacer) subtrees(a, b, d any) any {
+	m, ok := d.(map[string]any)
+	if !ok {
+		return d
+	}
+	if m["_t"] != "a" {
+		ma, ok1 := objectValues(a)
+		mb, ok2 := objectValues(b)
+		if !ok1 || !ok2 {
+			return d
+		}
+		for k, v := range m {
+			if _, nested := v.(map[string]any); !nested {
+				continue
+			}
+			nv := r.subtrees(ma[k], mb[k], v)
+			if nb := r.target(ma[k], mb[k], nv); r.larger(nv, nb) {
+				m[k] = []any{ma[k], nb}
+			} else {
+				m[k] = nv
+			}
+		}
+		return m
+	}
+
+	la, ok1 := a.([]any)
+	lb, ok2 := b.([]any)
+	if !ok1 || !ok2 {
+		return d
+	}
+	// Nested deltas sit at indices of b; the plan finds the items of a they
+	// change, which a replacement has to delete.
+	p := newArrayPlan()
+	if err := p.apply(m); err != nil {
+		return d
+	}
+	replaced := false
+	for i := range p.items {
+		s := &p.items[i]
+		if s.old < 0 || s.delta == nil || s.old >= len(la) || i >= len(lb) {
+			continue
+		}
+		nv := r.subtrees(la[s.old], lb[i], s.delta)
+		if nb := r.target(la[s.old], lb[i], nv); r.larger(nv, nb) {
+			p.deleted[s.old] = la[s.old]
+			*s = slot{old: -1, value: nb}
+			replaced = true
+		} else {
+			s.delta = nv
+			m[strconv.Itoa(i)] = nv
+		}
+	}
+	if replaced {
+		return p.delta()
+	}
+	return m
+}
+
+// replaceRoot returns a delta that replaces a with b, the value delta d
+// produces from it, without nesting.
+func replaceRoot(a, b any, d map[string]any) map[string]any {
+	ma, ok1 := objectValues(a)
+	mb, ok2 := objectValues(b)
+	if !ok1 || !ok2 || d["_t"] == "a" {
+		return map[string]any{rootKey: []any{a, b}}
+	}
+	out := make(map[string]any, len(d))
+	for k, v := range d {
+		if _, nested := v.(map[string]any); nested {
+			out[k] = []any{ma[k], mb[k]}
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
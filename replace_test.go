@@ -0,0 +1,146 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestDiffOrReplace_KeepsSmallDeltas(t *testing.T) {
+	a := map[string]any{"cfg": map[string]any{"name": "svc", "port": 80.0, "hosts": []any{"a", "b", "c"}}}
+	b := map[string]any{"cfg": map[string]any{"name": "svc", "port": 81.0, "hosts": []any{"a", "b", "c"}}}
+
+	got := DiffOrReplace(a, b, 0.5)
+	want := Diff(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+}
+
+func TestDiffOrReplace_ReplacesSubtrees(t *testing.T) {
+	a := map[string]any{
+		"small": map[string]any{"k": "v", "long": "unchanged unchanged unchanged"},
+		"churn": map[string]any{"x": 1.0, "y": 2.0},
+	}
+	b := map[string]any{
+		"small": map[string]any{"k": "w", "long": "unchanged unchanged unchanged"},
+		"churn": map[string]any{"p": 3.0, "q": 4.0},
+	}
+
+	got := DiffOrReplace(a, b, 0.9)
+	if _, nested := got["small"].(map[string]any); !nested {
+		t.Fatalf("expected a nested delta for small, got %v", got["small"])
+	}
+	want := []any{a["churn"], b["churn"]}
+	if !reflect.DeepEqual(got["churn"], want) {
+		t.Fatalf("unexpected delta for churn. got=%v want=%v", got["churn"], want)
+	}
+}
+
+func TestDiffOrReplace_Ordered(t *testing.T) {
+	a := parseOrdered(t, `{"small":{"k":"v","long":"unchanged unchanged unchanged"},"churn":{"x":1,"y":2}}`)
+	b := parseOrdered(t, `{"small":{"k":"w","long":"unchanged unchanged unchanged"},"churn":{"p":3,"q":4}}`)
+	got := DiffOrReplace(a, b, 0.9)
+	want := DiffOrReplace(parseJSON(t, marshalString(t, a)), parseJSON(t, marshalString(t, b)), 0.9)
+	if marshalString(t, got) != marshalString(t, want) {
+		t.Fatalf("unexpected delta. got=%s want=%s", marshalString(t, got), marshalString(t, want))
+	}
+	if _, nested := got["small"].(map[string]any); !nested {
+		t.Fatalf("expected a nested delta for small, got %v", got["small"])
+	}
+
+	// A delta larger than the threshold replaces the changed keys, not the
+	// root.
+	got = DiffOrReplace(a, b, 0.1)
+	if _, ok := got[rootKey]; ok || len(got) != 2 {
+		t.Fatalf("unexpected delta. got=%s", marshalString(t, got))
+	}
+	patched, err := Patch(map[string]any{"doc": a}, map[string]any{"doc": got})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := marshalString(t, patched["doc"]); s != marshalString(t, b) {
+		t.Fatalf("unexpected patch result. got=%s want=%s", s, marshalString(t, b))
+	}
+}
+
+func TestDiffOrReplace_ReplacesArrayItems(t *testing.T) {
+	pad := "a long item that stays the same"
+	a := map[string]any{"l": []any{pad, map[string]any{"a": 1.0, "b": 2.0}, pad}}
+	b := map[string]any{"l": []any{pad, map[string]any{"c": 3.0}, pad}}
+
+	got := DiffOrReplace(a, b, 0.9)
+	l := got["l"].(map[string]any)
+	if !reflect.DeepEqual(l["1"], []any{map[string]any{"c": 3.0}}) || l["_1"] == nil {
+		t.Fatalf("expected the item to be replaced, got %v", l)
+	}
+	patched, err := Patch(a, got)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !reflect.DeepEqual(patched, b) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, b)
+	}
+}
+
+func TestDiffOrReplace_ReplacesRoot(t *testing.T) {
+	a := map[string]any{"a": 1.0, "b": 2.0, "same": true}
+	b := map[string]any{"c": 3.0, "b": 4.0, "same": true}
+
+	got := DiffOrReplace(a, b, 0.1)
+	want := map[string]any{"a": []any{1.0, 0.0, 0.0}, "b": []any{2.0, 4.0}, "c": []any{3.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+
+	la, lb := []any{1.0, 2.0, 3.0}, []any{4.0, 5.0, 6.0}
+	got = DiffOrReplace(la, lb, 0.5)
+	if !reflect.DeepEqual(got, map[string]any{rootKey: []any{la, lb}}) {
+		t.Fatalf("unexpected delta for an array root: %v", got)
+	}
+}
+
+func TestDiffOrReplace_Options(t *testing.T) {
+	a := parseJSON(t, `{"churn":{"x":1,"y":2,"etag":"a"},"l":[{"p":1,"q":2,"etag":"a"}],"etag":"a"}`).(map[string]any)
+	b := parseJSON(t, `{"churn":{"z":3,"w":4,"etag":"b"},"l":[{"r":3,"s":4,"etag":"b"}],"etag":"b"}`).(map[string]any)
+
+	for _, threshold := range []float64{0.9, 0.01} {
+		got := DiffOrReplace(a, b, threshold, IgnoreKeys("etag"))
+		patched, err := Patch(a, got)
+		if err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		want := parseJSON(t, `{"churn":{"z":3,"w":4,"etag":"a"},"l":[{"r":3,"s":4,"etag":"a"}],"etag":"a"}`)
+		if !reflect.DeepEqual(patched, want) {
+			t.Fatalf("threshold %v: ignored keys changed. got=%v want=%v", threshold, patched, want)
+		}
+	}
+}
+
+func TestProperty_DiffOrReplace_Quick(t *testing.T) {
+	cfg := &quick.Config{
+		MaxCount: 200,
+		Rand:     newPseudoCryptoRand(),
+	}
+	prop := func(h jsonHistory, pct uint8) bool {
+		threshold := float64(pct%150) / 100
+		d := DiffOrReplace(h.V[0], h.V[1], threshold)
+		p, err := Patch(h.V[0], d)
+		if err != nil {
+			t.Logf("Patch failed: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(p, h.V[1]) {
+			b1, _ := json.Marshal(h.V[0])
+			b2, _ := json.Marshal(h.V[1])
+			dp, _ := json.Marshal(d)
+			t.Logf("threshold=%v\na=%s\nb=%s\ndelta=%s", threshold, b1, b2, dp)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package jsondiffgo
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// similarity scores how alike a and b are, from 0 when they share nothing to
+// 1 when they are equal under the differ's options. Objects score the
+// similarity of their common keys averaged over the keys of both sides, so
+// added and removed keys count against them. Arrays score the fraction of
+// items they have in common, in order. Other values score 1 when equal and 0
+// otherwise.
+func (d *differ) similarity(p Pointer, a, b any) float64 {
+	if d.equal(p, a, b) {
+		return 1
+	}
+	if ma, ok := objectValues(a); ok {
+		mb, ok := objectValues(b)
+		if !ok {
+			return 0
+		}
+		var sum float64
+		keys := 0
+		for k, va := range ma {
+			vb, shared := mb[k]
+			if d.customEqual && d.ignored(d.child(p, k), k, va, vb) {
+				continue
+			}
+			keys++
+			if shared {
+				sum += d.similarity(d.child(p, k), va, vb)
+			}
+		}
+		for k, vb := range mb {
+			if _, shared := ma[k]; !shared && !(d.customEqual && d.ignored(d.child(p, k), k, nil, vb)) {
+				keys++
+			}
+		}
+		if keys == 0 {
+			return 1
+		}
+		return sum / float64(keys)
+	}
+	if la, ok := a.([]any); ok {
+		lb, ok := b.([]any)
+		if !ok {
+			return 0
+		}
+		common := 0
+		for _, e := range d.align(p, la, lb) {
+			if eq, ok := e.(Equal); ok {
+				common += len(eq.Val)
+			}
+		}
+		return 2 * float64(common) / float64(len(la)+len(lb))
+	}
+	return 0
+}
+
+// diffSimilar is diffArray for PairSimilarItems. Items the edit script keeps
+// are matched in order; among the items it removes and inserts, similar
+// objects are paired regardless of their position. The delta then
+// deletes and inserts the unmatched items, diffs the paired ones at their new
+// index and moves those whose order changed.
+func (d *differ) diffSimilar(p Pointer, l1, l2 []any, edits []MyerDiff) any {
+	// match[i] is the new index of old item i, or -1 when it is removed.
+	match := make([]int, len(l1))
+	for i := range match {
+		match[i] = -1
+	}
+	kept := make([]bool, len(l2))
+	var removed, inserted []int
+	i, j := 0, 0
+	for _, e := range edits {
+		switch v := e.(type) {
+		case Equal:
+			for range v.Val {
+				match[i], kept[j] = j, true
+				i++
+				j++
+			}
+		case Delete:
+			for range v.Val {
+				removed = append(removed, i)
+				i++
+			}
+		case Insert:
+			for range v.Val {
+				inserted = append(inserted, j)
+				j++
+			}
+		}
+	}
+	pairs := d.pairSimilar(p, l1, l2, removed, inserted)
+	for _, pr := range pairs {
+		match[pr[0]], kept[pr[1]] = pr[1], true
+	}
+
+	out := map[string]any{}
+	for i, j := range match {
+		if j < 0 {
+			out["_"+strconv.Itoa(i)] = []any{l1[i], float64(0), float64(0)}
+		}
+	}
+	for j, ok := range kept {
+		if !ok {
+			out[strconv.Itoa(j)] = []any{l2[j]}
+		}
+	}
+	nested := make([]any, len(pairs))
+	d.forEach(len(pairs), func(k int) {
+		i, j := pairs[k][0], pairs[k][1]
+		nested[k] = d.diff(d.child(p, strconv.Itoa(j)), l1[i], l2[j])
+	})
+	for k, pr := range pairs {
+		if nested[k] != nil {
+			out[strconv.Itoa(pr[1])] = nested[k]
+		}
+	}
+	for _, m := range survivorMoves(match) {
+		out["_"+strconv.Itoa(m.src)] = []any{"", float64(m.dest), float64(3)}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	out["_t"] = "a"
+	return out
+}
+
+// pairSimilar pairs the removed and inserted objects and arrays whose
+// similarity reaches the threshold, the most similar first. It returns [old
+// index, new index] pairs.
+func (d *differ) pairSimilar(p Pointer, l1, l2 []any, removed, inserted []int) [][2]int {
+	type candidate struct {
+		pair  [2]int
+		score float64
+	}
+	var candidates []candidate
+	for _, i := range removed {
+		if !isContainer(l1[i]) {
+			continue
+		}
+		for _, j := range inserted {
+			if !isContainer(l2[j]) {
+				continue
+			}
+			if s := d.similarity(d.child(p, strconv.Itoa(j)), l1[i], l2[j]); s >= d.opts.similarity {
+				candidates = append(candidates, candidate{[2]int{i, j}, s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	usedOld, usedNew := map[int]bool{}, map[int]bool{}
+	var pairs [][2]int
+	for _, c := range candidates {
+		if usedOld[c.pair[0]] || usedNew[c.pair[1]] {
+			continue
+		}
+		usedOld[c.pair[0]], usedNew[c.pair[1]] = true, true
+		pairs = append(pairs, c.pair)
+	}
+	return pairs
+}
+
+// survivorMoves returns the moves that put the items surviving an array edit
+// in their new order; match[i] is the new index of item i, or -1 when it is
+// removed. Patch applies moves one at a time by increasing destination, an
+// index among the survivors, so destinations must increase strictly.
+//
+// The longest run of survivors already in order stays put and the others
+// are moved, by increasing new index, right after their new predecessor. When
+// that does not yield increasing destinations, every survivor out of place
+// is moved to its final index instead.
+func survivorMoves(match []int) []moveOp {
+	var order []int
+	for i, j := range match {
+		if j >= 0 {
+			order = append(order, i)
+		}
+	}
+	target := slices.Clone(order)
+	sort.Slice(target, func(a, b int) bool { return match[target[a]] < match[target[b]] })
+	if slices.Equal(order, target) {
+		return nil
+	}
+	rank := make(map[int]int, len(target))
+	for r, i := range target {
+		rank[i] = r
+	}
+	ranks := make([]int, len(order))
+	for k, i := range order {
+		ranks[k] = rank[i]
+	}
+	stay := map[int]bool{}
+	for _, k := range longestIncreasing(ranks) {
+		stay[order[k]] = true
+	}
+
+	list := slices.Clone(order)
+	var moves []moveOp
+	for r, i := range target {
+		if stay[i] {
+			continue
+		}
+		cur := slices.Index(list, i)
+		list = slices.Delete(list, cur, cur+1)
+		dest := 0
+		if r > 0 {
+			dest = slices.Index(list, target[r-1]) + 1
+		}
+		if len(moves) > 0 && dest <= moves[len(moves)-1].dest {
+			return prefixMoves(order, target)
+		}
+		list = slices.Insert(list, dest, i)
+		moves = append(moves, moveOp{src: i, dest: dest})
+	}
+	return moves
+}
+
+// prefixMoves moves every item of order that is not at its index in target
+// there, by increasing index.
+func prefixMoves(order, target []int) []moveOp {
+	list := slices.Clone(order)
+	var moves []moveOp
+	for r, i := range target {
+		if list[r] == i {
+			continue
+		}
+		cur := slices.Index(list, i)
+		list = slices.Delete(list, cur, cur+1)
+		list = slices.Insert(list, r, i)
+		moves = append(moves, moveOp{src: i, dest: r})
+	}
+	return moves
+}
+
+// longestIncreasing returns the positions of a longest strictly increasing
+// subsequence of seq, in order.
+func longestIncreasing(seq []int) []int {
+	// tails[l] is the position of the smallest tail of an increasing
+	// subsequence of length l+1; prev links each position to its predecessor.
+	var tails []int
+	prev := make([]int, len(seq))
+	for k, v := range seq {
+		l := sort.Search(len(tails), func(t int) bool { return seq[tails[t]] >= v })
+		prev[k] = -1
+		if l > 0 {
+			prev[k] = tails[l-1]
+		}
+		if l == len(tails) {
+			tails = append(tails, k)
+		} else {
+			tails[l] = k
+		}
+	}
+	out := make([]int, len(tails))
+	if len(tails) == 0 {
+		return out
+	}
+	for k, l := tails[len(tails)-1], len(tails)-1; l >= 0; l-- {
+		out[l] = k
+		k = prev[k]
+	}
+	return out
+}
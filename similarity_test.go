@@ -0,0 +1,143 @@
+package jsondiffgo
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPairSimilarItems_ShiftedRecords(t *testing.T) {
+	a := parseJSON(t, `{"users":[{"id":1,"name":"ann","age":30},{"id":2,"name":"bob","age":40}]}`).(map[string]any)
+	b := parseJSON(t, `{"users":[{"id":2,"name":"bob","age":41},{"id":1,"name":"ann","age":31}]}`).(map[string]any)
+
+	d := Diff(a, b, PairSimilarItems(0.5))
+	want := parseJSON(t, `{"users":{"_t":"a","_0":["",1,3],"0":{"age":[40,41]},"1":{"age":[30,31]}}}`)
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", d, want)
+	}
+	got, err := Patch(a, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", got, b)
+	}
+
+	// Below the threshold, records are replaced whole.
+	d = Diff(a, b, PairSimilarItems(0.9))
+	want = map[string]any{"users": map[string]any{
+		"_t": "a",
+		"_0": []any{a["users"].([]any)[0], 0.0, 0.0},
+		"_1": []any{a["users"].([]any)[1], 0.0, 0.0},
+		"0":  []any{b["users"].([]any)[0]},
+		"1":  []any{b["users"].([]any)[1]},
+	}}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", d, want)
+	}
+}
+
+func TestPairSimilarItems_KeepsUnchangedItems(t *testing.T) {
+	a := parseJSON(t, `[{"k":"a","v":1},"x",{"k":"b","v":2},{"k":"c","v":3}]`)
+	b := parseJSON(t, `[{"k":"a","v":1},{"k":"c","v":4},"x",{"k":"b","v":2},{"k":"d","v":5}]`)
+	d := Diff(a, b, PairSimilarItems(0.5))
+	want := parseJSON(t, `{"_t":"a","_3":["",1,3],"1":{"v":[3,4]},"4":[{"k":"d","v":5}]}`)
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", d, want)
+	}
+}
+
+func TestSurvivorMoves_AllPermutations(t *testing.T) {
+	var permute func(p []int, k int)
+	permute = func(p []int, k int) {
+		if k == len(p) {
+			checkSurvivorMoves(t, p)
+			return
+		}
+		for i := k; i < len(p); i++ {
+			p[k], p[i] = p[i], p[k]
+			permute(p, k+1)
+			p[k], p[i] = p[i], p[k]
+		}
+	}
+	for n := 0; n <= 7; n++ {
+		p := make([]int, n)
+		for i := range p {
+			p[i] = i
+		}
+		permute(p, 0)
+	}
+}
+
+// checkSurvivorMoves applies the moves for match the way Patch does and
+// checks that they produce the new order.
+func checkSurvivorMoves(t *testing.T, match []int) {
+	t.Helper()
+	moves := survivorMoves(match)
+	for k := 1; k < len(moves); k++ {
+		if moves[k].dest <= moves[k-1].dest {
+			t.Fatalf("destinations do not increase for %v: %v", match, moves)
+		}
+	}
+	res := make([]any, len(match))
+	kept := make([]int, len(match))
+	for i := range match {
+		res[i], kept[i] = i, i
+	}
+	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] })
+	res, _ = applyArrayMoves(res, kept, moves)
+	for pos, v := range res {
+		if match[v.(int)] != pos {
+			t.Fatalf("moves %v for %v produce %v", moves, match, res)
+		}
+	}
+}
+
+func TestPairSimilarItems_RandomEdits(t *testing.T) {
+	r := rand.New(rand.NewSource(47))
+	record := func(id int) map[string]any {
+		return map[string]any{"id": float64(id), "name": "n" + strconv.Itoa(id), "tags": []any{"t", float64(id % 3)}}
+	}
+	for iter := 0; iter < 2000; iter++ {
+		n := r.Intn(8)
+		l1 := make([]any, n)
+		for i := range l1 {
+			if r.Intn(5) == 0 {
+				l1[i] = float64(r.Intn(3))
+			} else {
+				l1[i] = record(i)
+			}
+		}
+		l2 := make([]any, 0, n+2)
+		for _, v := range l1 {
+			if r.Intn(4) == 0 {
+				continue
+			}
+			if m, ok := v.(map[string]any); ok && r.Intn(2) == 0 {
+				m = deepCopy(m).(map[string]any)
+				m["name"] = "edited"
+				v = m
+			}
+			l2 = append(l2, v)
+		}
+		r.Shuffle(len(l2), func(i, j int) { l2[i], l2[j] = l2[j], l2[i] })
+		for k := r.Intn(3); k > 0; k-- {
+			at := r.Intn(len(l2) + 1)
+			l2 = append(l2[:at], append([]any{record(100 + k)}, l2[at:]...)...)
+		}
+
+		a := map[string]any{"l": l1}
+		b := map[string]any{"l": l2}
+		for _, threshold := range []float64{0.3, 0.6, 1} {
+			d := Diff(a, b, PairSimilarItems(threshold))
+			got, err := Patch(a, d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, b) {
+				t.Fatalf("threshold %v: patching %v with %v gives %v, want %v", threshold, a, d, got, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package jsondiffgo
+
+import "encoding/json"
+
+// DeltaStats summarizes a delta. Every change marker counts as one leaf, so
+// an added object counts once however large it is.
+type DeltaStats struct {
+	Added    int // [new] entries
+	Removed  int // [old, 0, 0] entries
+	Modified int // [old, new] entries and text diffs
+	Moved    int // array moves, ["", dest, 3]
+
+	// TouchedArrays counts the array deltas, nested ones included.
+	TouchedArrays int
+
+	// DeepestPath is the location of the most deeply nested change; when
+	// several are equally deep the first in key order is reported. Deleted
+	// and moved array items are located by their original index.
+	DeepestPath Pointer
+	Depth       int
+
+	// DeltaBytes is the size of the delta encoded as JSON.
+	DeltaBytes int
+}
+
+// Stats walks delta and counts its changes by kind. A delta wrapping a
+// non-object root under "_root" is reported relative to the root.
+func Stats(delta map[string]any) DeltaStats {
+	s := DeltaStats{DeltaBytes: encodedSize(delta)}
+	if v, ok := delta[rootKey]; ok && len(delta) == 1 {
+		s.walk(Pointer{}, v)
+	} else if len(delta) > 0 {
+		s.walk(Pointer{}, delta)
+	}
+	return s
+}
+
+// Ratio returns the size of the delta relative to right, the full document
+// it produces. Values above 1 mean sending right is cheaper than the delta.
+func (s DeltaStats) Ratio(right any) float64 {
+	n := encodedSize(right)
+	if n == 0 {
+		return 0
+	}
+	return float64(s.DeltaBytes) / float64(n)
+}
+
+func (s *DeltaStats) walk(p Pointer, v any) {
+	m, ok := objectValues(v)
+	if !ok {
+		s.leaf(p, v)
+		return
+	}
+	isArray := m["_t"] == "a"
+	if isArray {
+		s.TouchedArrays++
+	}
+	for _, k := range sortedKeys(m) {
+		val := m[k]
+		if !isArray {
+			s.walk(p.child(k), val)
+			continue
+		}
+		if k == "_t" {
+			continue
+		}
+		if len(k) > 1 && k[0] == '_' {
+			// Array deletions and moves are keyed by the original index.
+			if splitUnderscore(k, val) {
+				s.Removed++
+				s.deepest(p.child(k[1:]))
+				continue
+			}
+			if arr, ok := val.([]any); ok && len(arr) == 3 {
+				if n, ok := toNumber(arr[2]); ok && n == 3 {
+					s.Moved++
+					s.deepest(p.child(k[1:]))
+					continue
+				}
+			}
+		}
+		s.walk(p.child(k), val)
+	}
+}
+
+// leaf counts a single marker the way doPatchMerge interprets it.
+func (s *DeltaStats) leaf(p Pointer, v any) {
+	switch deltaKindOf(v) {
+	case kindAdd:
+		s.Added++
+	case kindDelete:
+		s.Removed++
+	default:
+		// Replacements, text diffs and bare values all overwrite the old one.
+		s.Modified++
+	}
+	s.deepest(p)
+}
+
+func (s *DeltaStats) deepest(p Pointer) {
+	if s.DeepestPath == nil || len(p) > s.Depth {
+		s.DeepestPath, s.Depth = p, len(p)
+	}
+}
+
+// encodedSize returns the length of v encoded as JSON, or 0 if it cannot be
+// encoded.
+func encodedSize(v any) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
@@ -0,0 +1,70 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStats_CountsMarkers(t *testing.T) {
+	delta := map[string]any{
+		"name":  []any{"old", "new"},
+		"added": []any{map[string]any{"big": []any{1.0, 2.0}}},
+		"gone":  []any{1.0, 0.0, 0.0},
+		"text":  []any{"@@ -1 +1 @@", 0.0, 2.0},
+		"deep": map[string]any{
+			"list": map[string]any{
+				"_t": "a",
+				"_0": []any{"x", 0.0, 0.0},
+				"_2": []any{"", 0.0, 3.0},
+				"3":  []any{"y"},
+				"1": map[string]any{
+					"k": []any{true, false},
+				},
+			},
+		},
+	}
+	got := Stats(delta)
+	b, _ := json.Marshal(delta)
+	want := DeltaStats{
+		Added:         2,
+		Removed:       2,
+		Modified:      3,
+		Moved:         1,
+		TouchedArrays: 1,
+		DeepestPath:   Pointer{"deep", "list", "1", "k"},
+		Depth:         4,
+		DeltaBytes:    len(b),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected stats. got=%+v want=%+v", got, want)
+	}
+}
+
+func TestStats_RootAndEmpty(t *testing.T) {
+	got := Stats(Diff([]any{1.0}, []any{1.0, 2.0}))
+	if got.Added != 1 || got.TouchedArrays != 1 || got.DeepestPath.String() != "/1" {
+		t.Fatalf("unexpected stats for an array root: %+v", got)
+	}
+	got = Stats(Diff("a", "b"))
+	if got.Modified != 1 || got.DeepestPath == nil || got.Depth != 0 {
+		t.Fatalf("unexpected stats for a scalar root: %+v", got)
+	}
+	got = Stats(map[string]any{})
+	if !reflect.DeepEqual(got, DeltaStats{DeltaBytes: 2}) {
+		t.Fatalf("unexpected stats for an empty delta: %+v", got)
+	}
+}
+
+func TestStats_Ratio(t *testing.T) {
+	a := map[string]any{"list": []any{"a", "b", "c", "d"}, "v": 1.0}
+	b := map[string]any{"list": []any{"a", "b", "c", "d"}, "v": 2.0}
+	s := Stats(Diff(a, b))
+	if r := s.Ratio(b); r <= 0 || r >= 1 {
+		t.Fatalf("expected a small delta, ratio=%v", r)
+	}
+	c := map[string]any{"list": []any{"w", "x", "y", "z"}, "v": 1.0}
+	if r := Stats(Diff(a, c)).Ratio(c); r <= 1 {
+		t.Fatalf("expected a delta larger than the document, ratio=%v", r)
+	}
+}
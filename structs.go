@@ -0,0 +1,517 @@
+package jsondiffgo
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DiffStructs diffs two Go values the way Diff would diff them after a
+// json.Marshal and json.Unmarshal round trip, following the encoding/json
+// rules for struct tags (renames, "-", omitempty, omitzero, string),
+// embedded structs, map keys, []byte and json.Marshaler. The values are
+// converted directly, without encoding them to bytes, except for types that
+// implement json.Marshaler.
+func DiffStructs(a, b any, opts ...Option) (map[string]any, error) {
+	av, err := toJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bv, err := toJSONValue(b)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(av, bv, opts...), nil
+}
+
+// toJSONValue converts v into the value json.Unmarshal into an `any` would
+// produce from json.Marshal(v): maps, []any, float64, string, bool and nil.
+func toJSONValue(v any) (any, error) {
+	e := jsonConverter{seen: map[visitKey]struct{}{}}
+	return e.value(reflect.ValueOf(v), false)
+}
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	numberType        = reflect.TypeFor[json.Number]()
+)
+
+type jsonConverter struct {
+	// seen holds the pointers, maps and slices on the current path, to
+	// report cycles instead of recursing forever.
+	seen map[visitKey]struct{}
+}
+
+type visitKey struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+func (e *jsonConverter) value(v reflect.Value, quoted bool) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(marshalerType) {
+		return e.marshaler(v.Addr())
+	}
+	if t.Implements(marshalerType) {
+		return e.marshaler(v)
+	}
+	if t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(textMarshalerType) {
+		return textValue(v.Addr())
+	}
+	if t.Implements(textMarshalerType) {
+		return textValue(v)
+	}
+	if quoted {
+		return quotedValue(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return floatValueOf(v)
+	case reflect.String:
+		if t == numberType {
+			return numberValue(json.Number(v.String()))
+		}
+		return validUTF8(v.String()), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.value(v.Elem(), false)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.visit(visitKey{t, v.Pointer(), 0}, v, func() (any, error) { return e.value(v.Elem(), false) })
+	case reflect.Struct:
+		return e.structValue(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.visit(visitKey{t, v.Pointer(), 0}, v, func() (any, error) { return e.mapValue(v) })
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if isByteSlice(t) {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return e.visit(visitKey{t, v.Pointer(), v.Len()}, v, func() (any, error) { return e.arrayValue(v) })
+	case reflect.Array:
+		return e.arrayValue(v)
+	}
+	return nil, &json.UnsupportedTypeError{Type: t}
+}
+
+// visit runs fn while key is marked as being on the current path.
+func (e *jsonConverter) visit(key visitKey, v reflect.Value, fn func() (any, error)) (any, error) {
+	if _, ok := e.seen[key]; ok {
+		return nil, &json.UnsupportedValueError{Value: v, Str: fmt.Sprintf("encountered a cycle via %s", v.Type())}
+	}
+	e.seen[key] = struct{}{}
+	defer delete(e.seen, key)
+	return fn()
+}
+
+func (e *jsonConverter) marshaler(v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, nil
+	}
+	m, ok := v.Interface().(json.Marshaler)
+	if !ok {
+		return nil, nil
+	}
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	return out, nil
+}
+
+func textValue(v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, nil
+	}
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return nil, nil
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return nil, &json.MarshalerError{Type: v.Type(), Err: err}
+	}
+	return validUTF8(string(b)), nil
+}
+
+// quotedValue implements the ",string" tag option: the JSON encoding of the
+// value becomes a string.
+func quotedValue(v reflect.Value) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func floatValueOf(v reflect.Value) (any, error) {
+	f := v.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	if v.Kind() == reflect.Float32 {
+		// encoding/json prints float32 with 32-bit precision, which decodes
+		// to a different float64 than a plain conversion.
+		return strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+	}
+	return f, nil
+}
+
+func numberValue(n json.Number) (any, error) {
+	if n == "" {
+		return float64(0), nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, fmt.Errorf("json: invalid number literal %q", string(n))
+	}
+	return f, nil
+}
+
+// validUTF8 replaces each invalid byte with U+FFFD, as encoding/json does.
+func validUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteRune(utf8.RuneError)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func isByteSlice(t reflect.Type) bool {
+	if t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	p := reflect.PointerTo(t.Elem())
+	return !p.Implements(marshalerType) && !p.Implements(textMarshalerType)
+}
+
+func (e *jsonConverter) arrayValue(v reflect.Value) (any, error) {
+	out := make([]any, v.Len())
+	for i := range out {
+		item, err := e.value(v.Index(i), false)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = item
+	}
+	return out, nil
+}
+
+func (e *jsonConverter) mapValue(v reflect.Value) (any, error) {
+	out := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		item, err := e.value(iter.Value(), false)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = item
+	}
+	return out, nil
+}
+
+// mapKey follows encoding/json: string kinds first, then TextMarshaler, then
+// integers.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+func (e *jsonConverter) structValue(v reflect.Value) (any, error) {
+	out := map[string]any{}
+fields:
+	for _, f := range cachedFields(v.Type()) {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					// Fields promoted through a nil embedded pointer are omitted.
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if f.omitZero && isZeroValue(fv) {
+			continue
+		}
+		item, err := e.value(fv, f.quoted)
+		if err != nil {
+			return nil, err
+		}
+		out[f.name] = item
+	}
+	return out, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// isZeroValue implements omitzero: an IsZero method wins over the zero value.
+func isZeroValue(v reflect.Value) bool {
+	type isZeroer interface{ IsZero() bool }
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return true
+	}
+	if z, ok := v.Interface().(isZeroer); ok {
+		return z.IsZero()
+	}
+	if v.CanAddr() {
+		if z, ok := v.Addr().Interface().(isZeroer); ok {
+			return z.IsZero()
+		}
+	}
+	return v.IsZero()
+}
+
+// structField is a JSON object member resolved from a struct field.
+type structField struct {
+	name      string
+	tagged    bool
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]structField
+
+func cachedFields(t reflect.Type) []structField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]structField)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]structField)
+}
+
+// typeFields returns the fields encoding/json would encode for t, applying
+// the same breadth-first walk over embedded structs and the same rules for
+// resolving name conflicts.
+func typeFields(t reflect.Type) []structField {
+	var current []structField
+	next := []structField{{typ: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+	var fields []structField
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if !validTagName(name) {
+					name = ""
+				}
+				index := append(slices.Clip(f.index), i)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					field := structField{
+						name:      name,
+						tagged:    name != "",
+						index:     index,
+						typ:       ft,
+						omitEmpty: hasTagOption(opts, "omitempty"),
+						omitZero:  hasTagOption(opts, "omitzero"),
+						quoted:    hasTagOption(opts, "string") && quotable(ft),
+					}
+					if field.name == "" {
+						field.name = sf.Name
+					}
+					fields = append(fields, field)
+					if count[f.typ] > 1 {
+						// Duplicate embedded types at the same depth cancel
+						// each other out below.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, structField{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(fields, func(a, b structField) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := len(a.index) - len(b.index); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return slices.Compare(a.index, b.index)
+	})
+
+	// Keep only the dominant field for every name, per Go's embedding rules.
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != name {
+				break
+			}
+		}
+		group := fields[i : i+advance]
+		if len(group) > 1 && len(group[0].index) == len(group[1].index) && group[0].tagged == group[1].tagged {
+			continue
+		}
+		out = append(out, group[0])
+	}
+	return out
+}
+
+func hasTagOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+func quotable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return false
+}
+
+// validTagName mirrors the tag name check in encoding/json.
+func validTagName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
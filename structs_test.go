@@ -0,0 +1,150 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type structAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type structBase struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+	Shadow  string
+}
+
+type structLevel string
+
+func (l structLevel) MarshalText() ([]byte, error) { return []byte(strings.ToUpper(string(l))), nil }
+
+type structUser struct {
+	structBase
+	*structAddress
+	Name     string                 `json:"name"`
+	Nick     string                 `json:"nick,omitempty"`
+	Secret   string                 `json:"-"`
+	Dash     string                 `json:"-,"`
+	Age      uint8                  `json:"age,string"`
+	Score    float32                `json:"score"`
+	Tags     []string               `json:"tags"`
+	Avatar   []byte                 `json:"avatar,omitempty"`
+	Counts   map[int]string         `json:"counts"`
+	Levels   map[string]structLevel `json:"levels"`
+	Manager  *structUser            `json:"manager,omitempty"`
+	Extra    any                    `json:"extra"`
+	Raw      json.RawMessage        `json:"raw,omitempty"`
+	Number   json.Number            `json:"number,omitempty"`
+	Started  time.Time              `json:"started,omitzero"`
+	Shadow   int                    `json:"Shadow"`
+	internal int
+}
+
+// roundTripDiff is the reference behavior DiffStructs must reproduce.
+func roundTripDiff(t *testing.T, a, b any) map[string]any {
+	t.Helper()
+	decode := func(v any) any {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var out any
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return out
+	}
+	return Diff(decode(a), decode(b))
+}
+
+func TestDiffStructs_MatchesRoundTrip(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := structUser{
+		structBase:    structBase{ID: 1, Created: when, Shadow: "hidden"},
+		structAddress: &structAddress{Street: "Main"},
+		Name:          "Ann\xff",
+		Secret:        "s1",
+		Dash:          "d",
+		Age:           30,
+		Score:         0.1,
+		Tags:          []string{"a", "b"},
+		Counts:        map[int]string{1: "one"},
+		Levels:        map[string]structLevel{"x": "low"},
+		Extra:         map[string]int{"n": 1},
+		Raw:           json.RawMessage(`{"k":[1,2]}`),
+		Number:        "12.50",
+		internal:      1,
+	}
+	b := a
+	b.structBase.ID = 2
+	b.structAddress = &structAddress{Street: "Main", City: "Oslo"}
+	b.Nick = "annie"
+	b.Secret = "s2"
+	b.Age = 31
+	b.Score = 0.2
+	b.Tags = []string{"b", "c"}
+	b.Avatar = []byte{1, 2, 3}
+	b.Counts = map[int]string{1: "uno", 2: "two"}
+	b.Levels = map[string]structLevel{"x": "high"}
+	b.Manager = &structUser{Name: "Bob"}
+	b.Extra = []any{1, "x"}
+	b.Raw = json.RawMessage(`{"k":[1,3]}`)
+	b.Started = when
+	b.Shadow = 7
+
+	got, err := DiffStructs(a, b)
+	if err != nil {
+		t.Fatalf("DiffStructs failed: %v", err)
+	}
+	want := roundTripDiff(t, a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffStructs mismatch\n got=%v\nwant=%v", got, want)
+	}
+	// Pointers to structs and unchanged values behave the same way.
+	if got, err := DiffStructs(&a, &a); err != nil || len(got) != 0 {
+		t.Fatalf("expected empty diff, got=%v err=%v", got, err)
+	}
+}
+
+func TestDiffStructs_EmbeddedConflicts(t *testing.T) {
+	type A struct{ X, Y int }
+	type B struct {
+		X int
+		Y int `json:"Y"`
+	}
+	type C struct {
+		A
+		B
+	}
+	// X is ambiguous and dropped; the tagged Y wins.
+	got, err := toJSONValue(C{A{1, 2}, B{3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"Y": float64(4)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got=%v want=%v", got, want)
+	}
+}
+
+func TestDiffStructs_Errors(t *testing.T) {
+	type node struct {
+		Next *node `json:"next"`
+	}
+	n := &node{}
+	n.Next = n
+	var unsupported *json.UnsupportedValueError
+	if _, err := DiffStructs(n, nil); !errors.As(err, &unsupported) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	var badType *json.UnsupportedTypeError
+	if _, err := DiffStructs(map[string]any{"c": make(chan int)}, nil); !errors.As(err, &badType) {
+		t.Fatalf("expected unsupported type error, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSubDelta is returned by DeltaAt when the subtree at the path does not
+// exist on both sides of the delta, because it or one of its parents is added
+// or removed, so no delta can turn one into the other.
+var ErrNoSubDelta = errors.New("jsondiffgo: no delta for the subtree")
+
+// DeltaAt returns the part of delta that applies to the subtree at path, in
+// the form Diff would return for that subtree: Patch applies it to an object
+// subtree, and PatchOf to other values. Array items on the path are addressed
+// by their index in the document delta applies to; moves, insertions and
+// deletions before them are taken into account to find their changes. A
+// subtree inside a replaced value gets the delta between its old and new
+// version. The result is empty when nothing changes under path, and shares
+// its values with delta.
+func DeltaAt(delta map[string]any, path Pointer) (map[string]any, error) {
+	var v any = delta
+	if r, ok := delta[rootKey]; ok && len(delta) == 1 {
+		v = r
+	}
+	for i, tok := range path {
+		if v == nil {
+			return map[string]any{}, nil
+		}
+		m, ok := objectValues(v)
+		if !ok {
+			return deltaInValue(v, path[:i], path[i:])
+		}
+		if m["_t"] != "a" {
+			v = m[tok]
+			continue
+		}
+		idx, err := strconv.Atoi(tok)
+		if err != nil || idx < 0 {
+			return nil, fmt.Errorf("%w: %q at %s is not an array index", ErrInvalidPointer, tok, path[:i])
+		}
+		at, ok := arrayIndexAfter(m, idx)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is removed", ErrNoSubDelta, path[:i+1])
+		}
+		v = m[strconv.Itoa(at)]
+	}
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	if m, ok := objectValues(v); ok {
+		return m, nil
+	}
+	return deltaInValue(v, path, nil)
+}
+
+// deltaInValue returns the delta for the subtree at rest below the change v,
+// which is located at p.
+func deltaInValue(v any, p, rest Pointer) (map[string]any, error) {
+	switch deltaKindOf(v) {
+	case kindReplace:
+		arr := v.([]any)
+		old, ok1 := valueAt(arr[0], rest)
+		next, ok2 := valueAt(arr[1], rest)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%w: %s is replaced by a value without %s", ErrNoSubDelta, p, rest)
+		}
+		return Diff(old, next), nil
+	case kindAdd:
+		return nil, fmt.Errorf("%w: %s is added", ErrNoSubDelta, p)
+	case kindDelete:
+		return nil, fmt.Errorf("%w: %s is removed", ErrNoSubDelta, p)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: cannot descend into the change at %s", ErrNoSubDelta, p)
+	}
+	return map[string]any{rootKey: v}, nil
+}
+
+// valueAt returns the value at p inside v.
+func valueAt(v any, p Pointer) (any, bool) {
+	for _, tok := range p {
+		if m, ok := objectValues(v); ok {
+			if v, ok = m[tok]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		l, ok := v.([]any)
+		if !ok {
+			return nil, false
+		}
+		i, err := strconv.Atoi(tok)
+		if err != nil || i < 0 || i >= len(l) {
+			return nil, false
+		}
+		v = l[i]
+	}
+	return v, true
+}
+
+// arrayIndexAfter returns the index that item idx of an array has once the
+// array delta m is applied. It replays the deletions, moves and insertions of
+// m the way Patch does, on item numbers instead of values.
+func arrayIndexAfter(m map[string]any, idx int) (int, bool) {
+	n := idx + 1
+	shape := map[string]any{}
+	for k, v := range m {
+		if k == "_t" {
+			continue
+		}
+		if strings.HasPrefix(k, "_") {
+			if i, err := strconv.Atoi(k[1:]); err == nil {
+				n = max(n, i+1)
+			}
+			if arr, ok := v.([]any); ok && len(arr) == 3 {
+				if dest, ok := toNumber(arr[1]); ok {
+					n = max(n, int(dest)+1)
+				}
+			}
+			shape[k] = v
+		} else if deltaKindOf(v) == kindAdd {
+			shape[k] = []any{nil}
+		}
+	}
+	list := make([]any, n)
+	for i := range list {
+		list[i] = i
+	}
+	res, err := applyArrayPatch(list, shape, patchInPlace)
+	if err != nil {
+		return 0, false
+	}
+	for i, x := range res {
+		if x == idx {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// WrapDelta embeds sub, a delta for the subtree at path, into a delta for
+// the whole document doc, so that Patch(doc, WrapDelta(doc, path, sub))
+// changes only that subtree. sub takes the form Diff returns for the
+// subtree. A JSON Pointer does not tell array indices from object keys, but
+// the delta format does, so each token is resolved against doc, the document
+// the result applies to; path must exist in it. The result shares its values
+// with sub.
+func WrapDelta(doc any, path Pointer, sub map[string]any) (map[string]any, error) {
+	if len(sub) == 0 {
+		return map[string]any{}, nil
+	}
+	if len(path) == 0 {
+		return sub, nil
+	}
+	arrays := make([]bool, len(path))
+	v := doc
+	for i, tok := range path {
+		if m, ok := objectValues(v); ok {
+			if v, ok = m[tok]; !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPointer, path[:i+1])
+			}
+			continue
+		}
+		l, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an object or array", ErrInvalidPointer, path[:i])
+		}
+		idx, err := strconv.Atoi(tok)
+		if err != nil || idx < 0 || idx >= len(l) || strconv.Itoa(idx) != tok {
+			return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPointer, path[:i+1])
+		}
+		arrays[i] = true
+		v = l[idx]
+	}
+
+	var inner any = sub
+	if r, ok := sub[rootKey]; ok && len(sub) == 1 {
+		inner = r
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if arrays[i] {
+			inner = map[string]any{"_t": "a", path[i]: inner}
+		} else {
+			inner = map[string]any{path[i]: inner}
+		}
+	}
+	return inner.(map[string]any), nil
+}
@@ -0,0 +1,158 @@
+package jsondiffgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func mustPointer(t *testing.T, s string) Pointer {
+	t.Helper()
+	p, err := ParsePointer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDeltaAt_Object(t *testing.T) {
+	a := parseJSON(t, `{"services":{"api":{"image":"v1","env":{"A":"1"}},"db":{"image":"pg"}},"name":"x"}`).(map[string]any)
+	b := parseJSON(t, `{"services":{"api":{"image":"v2","env":{"A":"1","B":"2"}},"db":{"image":"pg"}},"name":"y"}`).(map[string]any)
+	d := Diff(a, b)
+
+	sub, err := DeltaAt(d, mustPointer(t, "/services/api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := parseJSON(t, `{"image":["v1","v2"],"env":{"B":["2"]}}`)
+	if !reflect.DeepEqual(sub, want) {
+		t.Fatalf("unexpected sub-delta. got=%v want=%v", sub, want)
+	}
+	api := a["services"].(map[string]any)["api"].(map[string]any)
+	patched, err := Patch(api, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantAPI := b["services"].(map[string]any)["api"]; !reflect.DeepEqual(patched, wantAPI) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, wantAPI)
+	}
+
+	for _, p := range []string{"/services/db", "/services/db/image", "/missing"} {
+		if sub, err := DeltaAt(d, mustPointer(t, p)); err != nil || len(sub) != 0 {
+			t.Fatalf("expected no changes at %s, got %v, %v", p, sub, err)
+		}
+	}
+	sub, err = DeltaAt(d, mustPointer(t, "/name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{rootKey: []any{"x", "y"}}; !reflect.DeepEqual(sub, want) {
+		t.Fatalf("unexpected sub-delta. got=%v want=%v", sub, want)
+	}
+	if v, err := PatchOf("x", Delta(sub)); err != nil || v != "y" {
+		t.Fatalf("unexpected PatchOf result: %v, %v", v, err)
+	}
+}
+
+func TestDeltaAt_ArrayIndices(t *testing.T) {
+	a := parseJSON(t, `{"items":[{"id":"a"},{"id":"b"},{"id":"c","v":1}]}`)
+	b := parseJSON(t, `{"items":[{"id":"x"},{"id":"a"},{"id":"b"},{"id":"c","v":2}]}`)
+	d := Diff(a, b, PairSimilarItems(0.5))
+	sub, err := DeltaAt(d, mustPointer(t, "/items/2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseJSON(t, `{"v":[1,2]}`); !reflect.DeepEqual(sub, want) {
+		t.Fatalf("unexpected sub-delta. got=%v want=%v", sub, want)
+	}
+
+	// Moved items are found by their original index.
+	moved := parseJSON(t, `{"users":{"_t":"a","_0":["",1,3],"0":{"age":[40,41]},"1":{"age":[30,31]}}}`).(map[string]any)
+	sub, err = DeltaAt(moved, mustPointer(t, "/users/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseJSON(t, `{"age":[30,31]}`); !reflect.DeepEqual(sub, want) {
+		t.Fatalf("unexpected sub-delta. got=%v want=%v", sub, want)
+	}
+
+	removed := Diff(parseJSON(t, `{"l":[1,{"a":1},2]}`), parseJSON(t, `{"l":[1,2]}`))
+	if _, err := DeltaAt(removed, mustPointer(t, "/l/1/a")); !errors.Is(err, ErrNoSubDelta) {
+		t.Fatalf("expected ErrNoSubDelta, got %v", err)
+	}
+	if _, err := DeltaAt(removed, mustPointer(t, "/l/x")); !errors.Is(err, ErrInvalidPointer) {
+		t.Fatalf("expected ErrInvalidPointer, got %v", err)
+	}
+}
+
+func TestDeltaAt_InsideReplacedValue(t *testing.T) {
+	d := parseJSON(t, `{"cfg":[{"a":{"x":1},"b":1},{"a":{"x":2}}]}`).(map[string]any)
+	sub, err := DeltaAt(d, mustPointer(t, "/cfg/a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseJSON(t, `{"x":[1,2]}`); !reflect.DeepEqual(sub, want) {
+		t.Fatalf("unexpected sub-delta. got=%v want=%v", sub, want)
+	}
+	if _, err := DeltaAt(d, mustPointer(t, "/cfg/b")); !errors.Is(err, ErrNoSubDelta) {
+		t.Fatalf("expected ErrNoSubDelta, got %v", err)
+	}
+	added := parseJSON(t, `{"cfg":[{"a":1}]}`).(map[string]any)
+	if _, err := DeltaAt(added, mustPointer(t, "/cfg/a")); !errors.Is(err, ErrNoSubDelta) {
+		t.Fatalf("expected ErrNoSubDelta, got %v", err)
+	}
+}
+
+func TestWrapDelta(t *testing.T) {
+	doc := parseJSON(t, `{"users":[{"name":"a"},{"name":"b","byId":{"7":{"n":1}}}]}`).(map[string]any)
+
+	got, err := WrapDelta(doc, mustPointer(t, "/users/1/byId/7"), map[string]any{"n": []any{1.0, 2.0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := parseJSON(t, `{"users":{"_t":"a","1":{"byId":{"7":{"n":[1,2]}}}}}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+	patched, err := Patch(doc, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDoc := parseJSON(t, `{"users":[{"name":"a"},{"name":"b","byId":{"7":{"n":2}}}]}`)
+	if !reflect.DeepEqual(patched, wantDoc) {
+		t.Fatalf("unexpected patch result. got=%v want=%v", patched, wantDoc)
+	}
+
+	// Scalar subtrees take the form Diff returns for them.
+	got, err = WrapDelta(doc, mustPointer(t, "/users/0/name"), map[string]any{rootKey: []any{"a", "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parseJSON(t, `{"users":{"_t":"a","0":{"name":["a","c"]}}}`); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", got, want)
+	}
+
+	for _, p := range []string{"/users/2", "/users/01", "/users/0/name/x", "/missing"} {
+		if _, err := WrapDelta(doc, mustPointer(t, p), map[string]any{"x": []any{1.0}}); !errors.Is(err, ErrInvalidPointer) {
+			t.Fatalf("expected ErrInvalidPointer for %s, got %v", p, err)
+		}
+	}
+}
+
+func TestDeltaAt_WrapDeltaRoundTrip(t *testing.T) {
+	a := parseJSON(t, `{"spec":{"containers":[{"name":"app","ports":[80]},{"name":"side"}]},"meta":{"v":1}}`)
+	b := parseJSON(t, `{"spec":{"containers":[{"name":"app","ports":[80,443],"tty":true},{"name":"side"}]},"meta":{"v":1}}`)
+	d := Diff(a, b)
+	p := mustPointer(t, "/spec/containers/0")
+	sub, err := DeltaAt(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := WrapDelta(a, p, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Fatalf("unexpected round trip. got=%v want=%v", got, d)
+	}
+}
@@ -0,0 +1,617 @@
+package toml
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SyntaxError reports malformed TOML.
+type SyntaxError struct {
+	Line int // 1-based
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("toml: line %d: %s", e.Line, e.Msg)
+}
+
+// Datetime is a TOML date, time or date-time, kept as written, such as
+// "1979-05-27T07:32:00Z" or "07:32:00". It encodes to JSON as a string.
+type Datetime string
+
+// Unmarshal parses a TOML 1.0 document into the value model of
+// encoding/json: tables become map[string]any, arrays []any, strings string,
+// booleans bool, and dates and times Datetime. Integers and floats become
+// json.Number values, so that they keep their type and precision: integers
+// are written in decimal, as in 255 for 0xff, and floats as written, as in
+// 1.0, without underscores or a leading plus sign. inf and nan, which have no
+// JSON equivalent, are rejected.
+func Unmarshal(data []byte) (map[string]any, error) {
+	s := string(data)
+	if !utf8.ValidString(s) {
+		return nil, &SyntaxError{Line: 1, Msg: "invalid UTF-8"}
+	}
+	p := &parser{data: s, root: newTable()}
+	if err := p.document(); err != nil {
+		return nil, err
+	}
+	return p.root.value(), nil
+}
+
+// table is a table under construction. Its entries hold *table for tables
+// that may still receive keys, *tableArray for arrays of tables and final
+// values otherwise, inline tables and arrays included.
+type table struct {
+	entries  map[string]any
+	explicit bool // defined by a [header]
+	dotted   bool // defined by dotted keys
+}
+
+type tableArray struct {
+	tables []*table
+}
+
+func newTable() *table { return &table{entries: map[string]any{}} }
+
+func (t *table) value() map[string]any {
+	out := make(map[string]any, len(t.entries))
+	for k, v := range t.entries {
+		switch x := v.(type) {
+		case *table:
+			out[k] = x.value()
+		case *tableArray:
+			l := make([]any, len(x.tables))
+			for i, t := range x.tables {
+				l[i] = t.value()
+			}
+			out[k] = l
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+type parser struct {
+	data    string
+	pos     int
+	root    *table
+	current *table
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: strings.Count(p.data[:p.pos], "\n") + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.data) }
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to the end of the line.
+func (p *parser) skipComment() {
+	if p.peek() == '#' {
+		if i := strings.IndexByte(p.data[p.pos:], '\n'); i >= 0 {
+			p.pos += i
+		} else {
+			p.pos = len(p.data)
+		}
+	}
+}
+
+// newline consumes a line break, reporting whether there was one.
+func (p *parser) newline() bool {
+	switch {
+	case strings.HasPrefix(p.data[p.pos:], "\n"):
+		p.pos++
+	case strings.HasPrefix(p.data[p.pos:], "\r\n"):
+		p.pos += 2
+	default:
+		return false
+	}
+	return true
+}
+
+// endLine expects the rest of the line to hold at most a comment.
+func (p *parser) endLine() error {
+	p.skipSpaces()
+	p.skipComment()
+	if !p.eof() && !p.newline() {
+		return p.errorf("expected the end of the line, found %q", p.data[p.pos:min(p.pos+10, len(p.data))])
+	}
+	return nil
+}
+
+func (p *parser) document() error {
+	p.current = p.root
+	for {
+		for {
+			p.skipSpaces()
+			p.skipComment()
+			if !p.newline() {
+				break
+			}
+		}
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.header()
+		} else {
+			err = p.keyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) header() error {
+	array := strings.HasPrefix(p.data[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpaces()
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.data[p.pos:], closing) {
+		return p.errorf("expected %q after table name", closing)
+	}
+	p.pos += len(closing)
+
+	t := p.root
+	for _, k := range keys[:len(keys)-1] {
+		switch x := t.entries[k].(type) {
+		case nil:
+			nt := newTable()
+			t.entries[k] = nt
+			t = nt
+		case *table:
+			t = x
+		case *tableArray:
+			t = x.tables[len(x.tables)-1]
+		default:
+			return p.errorf("key %q is already defined", k)
+		}
+	}
+	last := keys[len(keys)-1]
+	x, exists := t.entries[last]
+	switch {
+	case array && !exists:
+		nt := newTable()
+		t.entries[last] = &tableArray{tables: []*table{nt}}
+		p.current = nt
+	case array:
+		ta, ok := x.(*tableArray)
+		if !ok {
+			return p.errorf("key %q is already defined", last)
+		}
+		nt := newTable()
+		ta.tables = append(ta.tables, nt)
+		p.current = nt
+	case !exists:
+		nt := newTable()
+		nt.explicit = true
+		t.entries[last] = nt
+		p.current = nt
+	default:
+		nt, ok := x.(*table)
+		if !ok || nt.explicit || nt.dotted {
+			return p.errorf("table %q is already defined", strings.Join(keys, "."))
+		}
+		nt.explicit = true
+		p.current = nt
+	}
+	return nil
+}
+
+// keyValue parses key = value into t.
+func (p *parser) keyValue(t *table) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key")
+	}
+	p.pos++
+	p.skipSpaces()
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys[:len(keys)-1] {
+		switch x := t.entries[k].(type) {
+		case nil:
+			nt := newTable()
+			nt.dotted = true
+			t.entries[k] = nt
+			t = nt
+		case *table:
+			if !x.dotted {
+				return p.errorf("table %q cannot be extended with dotted keys", k)
+			}
+			t = x
+		default:
+			return p.errorf("key %q is already defined", k)
+		}
+	}
+	last := keys[len(keys)-1]
+	if _, exists := t.entries[last]; exists {
+		return p.errorf("key %q is already defined", last)
+	}
+	t.entries[last] = v
+	return nil
+}
+
+// key parses a possibly dotted key.
+func (p *parser) key() ([]string, error) {
+	var keys []string
+	for {
+		var k string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		case c == '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.data[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			k = p.data[start:p.pos]
+		}
+		keys = append(keys, k)
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpaces()
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) value() (any, error) {
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.data[p.pos:], `"""`):
+		return p.multilineString('"')
+	case strings.HasPrefix(p.data[p.pos:], `'''`):
+		return p.multilineString('\'')
+	case c == '"':
+		return p.basicString()
+	case c == '\'':
+		return p.literalString()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.data[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.data[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	}
+	return p.scalar()
+}
+
+func (p *parser) array() ([]any, error) {
+	p.pos++
+	out := []any{}
+	for {
+		if err := p.arraySpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return out, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		if err := p.arraySpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return out, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// arraySpace skips whitespace, comments and line breaks inside an array.
+func (p *parser) arraySpace() error {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.eof() {
+			return p.errorf("unterminated array")
+		}
+		if !p.newline() {
+			return nil
+		}
+	}
+}
+
+func (p *parser) inlineTable() (map[string]any, error) {
+	p.pos++
+	t := newTable()
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		return t.value(), nil
+	}
+	for {
+		p.skipSpaces()
+		if err := p.keyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return t.value(), nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *parser) basicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			r, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case isControl(c):
+			return "", p.errorf("control character %q in string", c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) literalString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.data[p.pos:], "'\n")
+	if end < 0 || p.data[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.data[p.pos : p.pos+end]
+	for i := 0; i < len(s); i++ {
+		if isControl(s[i]) {
+			return "", p.errorf("control character %q in string", s[i])
+		}
+	}
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *parser) multilineString(q byte) (string, error) {
+	p.pos += 3
+	p.newline() // a line break right after the delimiter is trimmed
+	delim := strings.Repeat(string(q), 3)
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.data[p.pos:], delim) {
+			// Up to two quotes may directly precede the closing delimiter.
+			n := 3
+			for n < 5 && p.pos+n < len(p.data) && p.data[p.pos+n] == q {
+				n++
+			}
+			b.WriteString(strings.Repeat(string(q), n-3))
+			p.pos += n
+			return b.String(), nil
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '\\' && q == '"':
+			if rest := strings.TrimLeft(p.data[p.pos+1:], " \t"); strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				// A line ending backslash trims all whitespace up to the
+				// next non-whitespace character.
+				p.pos = len(p.data) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			r, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case p.newline():
+			b.WriteByte('\n')
+		case isControl(c):
+			return "", p.errorf("control character %q in string", c)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func isControl(c byte) bool { return c < 0x20 && c != '\t' || c == 0x7f }
+
+// escape decodes the escape sequence at the cursor.
+func (p *parser) escape() (rune, error) {
+	if p.pos+1 >= len(p.data) {
+		return 0, p.errorf("unterminated escape sequence")
+	}
+	c := p.data[p.pos+1]
+	if r, ok := map[byte]rune{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}[c]; ok {
+		p.pos += 2
+		return r, nil
+	}
+	size := map[byte]int{'u': 4, 'U': 8}[c]
+	if size == 0 || p.pos+2+size > len(p.data) {
+		return 0, p.errorf("invalid escape sequence \\%c", c)
+	}
+	hex := p.data[p.pos+2 : p.pos+2+size]
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, p.errorf("invalid escape sequence \\%c%s", c, hex)
+	}
+	p.pos += 2 + size
+	return rune(n), nil
+}
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	floatPattern   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	radixPattern   = regexp.MustCompile(`^0(x[0-9A-Fa-f](_?[0-9A-Fa-f])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	datePattern    = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	timePattern    = regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?$`)
+	offsetPattern  = regexp.MustCompile(`^([Zz]|[+-][0-9]{2}:[0-9]{2})$`)
+)
+
+// scalar parses a number, date or time.
+func (p *parser) scalar() (any, error) {
+	start := p.pos
+	for !p.eof() && strings.IndexByte("0123456789abcdefABCDEFxobintTzZ_+-.:", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+	// A space may separate the date from the time.
+	if datePattern.MatchString(p.data[start:p.pos]) && p.pos+2 < len(p.data) && p.data[p.pos] == ' ' &&
+		p.data[p.pos+1] >= '0' && p.data[p.pos+1] <= '9' {
+		p.pos++
+		for !p.eof() && strings.IndexByte("0123456789zZ+-.:", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+	}
+	tok := p.data[start:p.pos]
+	switch {
+	case tok == "":
+		return nil, p.errorf("expected a value")
+	case decimalPattern.MatchString(tok):
+		n := strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+")
+		if _, err := strconv.ParseInt(n, 10, 64); err != nil {
+			return nil, p.errorf("integer %s is out of range", tok)
+		}
+		return json.Number(n), nil
+	case floatPattern.MatchString(tok):
+		f := strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+")
+		if _, err := strconv.ParseFloat(f, 64); err != nil {
+			return nil, p.errorf("float %s is out of range", tok)
+		}
+		return json.Number(f), nil
+	case radixPattern.MatchString(tok):
+		n, err := strconv.ParseInt(strings.ReplaceAll(tok, "_", ""), 0, 64)
+		if err != nil {
+			return nil, p.errorf("integer %s is out of range", tok)
+		}
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	case strings.TrimLeft(tok, "+-") == "inf" || strings.TrimLeft(tok, "+-") == "nan":
+		return nil, p.errorf("%s has no JSON equivalent", tok)
+	case validDatetime(tok):
+		return Datetime(tok), nil
+	}
+	return nil, p.errorf("invalid value %q", tok)
+}
+
+func validDatetime(s string) bool {
+	checkTime := func(t string) bool {
+		_, err := time.Parse("15:04:05.999999999", t)
+		return timePattern.MatchString(t) && err == nil
+	}
+	if checkTime(s) {
+		return true
+	}
+	if len(s) < 10 || !datePattern.MatchString(s[:10]) {
+		return false
+	}
+	if _, err := time.Parse("2006-01-02", s[:10]); err != nil {
+		return false
+	}
+	if len(s) == 10 {
+		return true
+	}
+	if s[10] != 'T' && s[10] != 't' && s[10] != ' ' {
+		return false
+	}
+	t := s[11:]
+	if i := strings.IndexAny(t, "Zz+-"); i >= 0 {
+		if !offsetPattern.MatchString(t[i:]) {
+			return false
+		}
+		t = t[:i]
+	}
+	return checkTime(t)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
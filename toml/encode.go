@@ -0,0 +1,293 @@
+package toml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Marshal writes the table m as a TOML document. Keys are sorted, so equal
+// values always produce the same bytes. Within a table, plain keys come
+// first, then sub-tables, then arrays of tables; other arrays holding tables
+// are written inline.
+//
+// m is expected to hold the value model of encoding/json, plus Datetime;
+// other values are converted through their JSON encoding first. TOML has no
+// null, so nil values are rejected.
+//
+// A json.Number, as returned by Unmarshal, is written as it is: a TOML float
+// when it has a fraction or an exponent and an integer otherwise. A float64
+// is what encoding/json decodes every JSON number to, so integral float64
+// values are written as integers and others as floats. Decode deltas with
+// json.Decoder.UseNumber to keep 1.0 a float.
+func Marshal(m map[string]any) ([]byte, error) {
+	v, err := jsonValue(m)
+	if err != nil {
+		return nil, err
+	}
+	e := &encoder{}
+	if err := e.table(nil, v.(map[string]any)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// jsonValue converts v to the value model of encoding/json, keeping
+// Datetime values.
+func jsonValue(v any) (any, error) {
+	switch t := v.(type) {
+	case nil, bool, string, float64, int, int64, json.Number, Datetime:
+		return v, nil
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			c, err := jsonValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			c, err := jsonValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("toml: %w", err)
+	}
+	var out any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return nil, fmt.Errorf("toml: %w", err)
+	}
+	return out, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+// isTableArray reports whether v is written as an array of tables.
+func isTableArray(v any) bool {
+	l, ok := v.([]any)
+	if !ok || len(l) == 0 {
+		return false
+	}
+	for _, x := range l {
+		if _, ok := x.(map[string]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// table writes the keys of the table at path, whose header has already been
+// written.
+func (e *encoder) table(path []string, m map[string]any) error {
+	keys := sortedKeys(m)
+	for _, k := range keys {
+		v := m[k]
+		if _, ok := v.(map[string]any); ok || isTableArray(v) {
+			continue
+		}
+		e.key(k)
+		e.buf = append(e.buf, " = "...)
+		if err := e.value(append(path, k), v); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, '\n')
+	}
+	for _, k := range keys {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			continue
+		}
+		p := append(path[:len(path):len(path)], k)
+		if len(sub) == 0 || hasPlainKeys(sub) {
+			e.header("[", p, "]")
+		}
+		if err := e.table(p, sub); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if !isTableArray(m[k]) {
+			continue
+		}
+		p := append(path[:len(path):len(path)], k)
+		for _, x := range m[k].([]any) {
+			e.header("[[", p, "]]")
+			if err := e.table(p, x.(map[string]any)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasPlainKeys reports whether m holds values written as key = value.
+func hasPlainKeys(m map[string]any) bool {
+	for _, v := range m {
+		if _, ok := v.(map[string]any); !ok && !isTableArray(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *encoder) header(open string, path []string, closing string) {
+	if len(e.buf) > 0 {
+		e.buf = append(e.buf, '\n')
+	}
+	e.buf = append(e.buf, open...)
+	for i, k := range path {
+		if i > 0 {
+			e.buf = append(e.buf, '.')
+		}
+		e.key(k)
+	}
+	e.buf = append(e.buf, closing...)
+	e.buf = append(e.buf, '\n')
+}
+
+func (e *encoder) key(k string) {
+	bare := k != ""
+	for i := 0; i < len(k); i++ {
+		bare = bare && isBareKeyChar(k[i])
+	}
+	if bare {
+		e.buf = append(e.buf, k...)
+	} else {
+		e.str(k)
+	}
+}
+
+// value writes an inline value; path locates it for error messages.
+func (e *encoder) value(path []string, v any) error {
+	switch t := v.(type) {
+	case nil:
+		return fmt.Errorf("toml: cannot encode null at %s", strings.Join(path, "."))
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, t)
+	case float64:
+		if math.IsInf(t, 0) || math.IsNaN(t) {
+			return fmt.Errorf("toml: unsupported number %v at %s", t, strings.Join(path, "."))
+		}
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+		} else {
+			e.buf = appendFloat(e.buf, t)
+		}
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, t, 10)
+	case json.Number:
+		n, err := number(t)
+		if err != nil {
+			return fmt.Errorf("toml: %w at %s", err, strings.Join(path, "."))
+		}
+		e.buf = append(e.buf, n...)
+	case string:
+		e.str(t)
+	case Datetime:
+		e.buf = append(e.buf, t...)
+	case []any:
+		e.buf = append(e.buf, '[')
+		for i, x := range t {
+			if i > 0 {
+				e.buf = append(e.buf, ", "...)
+			}
+			if err := e.value(append(path, strconv.Itoa(i)), x); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, ']')
+	case map[string]any:
+		e.buf = append(e.buf, '{')
+		for i, k := range sortedKeys(t) {
+			if i > 0 {
+				e.buf = append(e.buf, ", "...)
+			}
+			e.key(k)
+			e.buf = append(e.buf, " = "...)
+			if err := e.value(append(path, k), t[k]); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, '}')
+	default:
+		return fmt.Errorf("toml: unsupported value %T at %s", v, strings.Join(path, "."))
+	}
+	return nil
+}
+
+func (e *encoder) str(s string) {
+	e.buf = append(e.buf, '"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			e.buf = append(e.buf, `\"`...)
+		case '\\':
+			e.buf = append(e.buf, `\\`...)
+		case '\b':
+			e.buf = append(e.buf, `\b`...)
+		case '\t':
+			e.buf = append(e.buf, `\t`...)
+		case '\n':
+			e.buf = append(e.buf, `\n`...)
+		case '\f':
+			e.buf = append(e.buf, `\f`...)
+		case '\r':
+			e.buf = append(e.buf, `\r`...)
+		default:
+			if r < 0x20 || r == 0x7f {
+				e.buf = fmt.Appendf(e.buf, `\u%04X`, r)
+			} else {
+				e.buf = append(e.buf, string(r)...)
+			}
+		}
+	}
+	e.buf = append(e.buf, '"')
+}
+
+// number returns the TOML form of n: integers as they are, and floats with
+// a fraction or an exponent so that they read back as floats.
+func number(n json.Number) (string, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return "", fmt.Errorf("integer %s is out of range", s)
+		}
+		return s, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || !jsonNumberPattern.MatchString(s) {
+		return "", fmt.Errorf("unsupported number %s", s)
+	}
+	return s, nil
+}
+
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// appendFloat writes f so that it reads back as a float.
+func appendFloat(b []byte, f float64) []byte {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return append(b, s...)
+}
@@ -0,0 +1,44 @@
+// Package toml diffs and patches TOML documents with jsondiffgo. Documents
+// are parsed into the value model of encoding/json, so their deltas are
+// ordinary jsondiffpatch deltas that can be stored, shipped and applied like
+// those of JSON documents.
+//
+// The parser and writer are self-contained and implement TOML 1.0. Comments
+// and the original key order are not preserved: Marshal sorts keys, so
+// output is stable. Dates and times are kept as Datetime values; a delta
+// decoded from JSON carries them as strings, which Marshal writes quoted.
+//
+// Numbers are json.Number values, so integers and floats keep their type and
+// precision through a patch. Decode deltas shipped as JSON with
+// json.Decoder.UseNumber to keep them.
+package toml
+
+import "github.com/jsondiffgo"
+
+// Diff parses two TOML documents and returns the delta between them, as
+// jsondiffgo.Diff does for JSON.
+func Diff(a, b []byte, opts ...jsondiffgo.Option) (map[string]any, error) {
+	ma, err := Unmarshal(a)
+	if err != nil {
+		return nil, err
+	}
+	mb, err := Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return jsondiffgo.Diff(ma, mb, opts...), nil
+}
+
+// Patch applies delta to the TOML document doc and returns the result as
+// written by Marshal.
+func Patch(doc []byte, delta map[string]any) ([]byte, error) {
+	m, err := Unmarshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := jsondiffgo.Patch(m, delta)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(patched)
+}
@@ -0,0 +1,399 @@
+package toml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const service = `# Service configuration
+title = "api"
+version = 3
+ratio = 0.75
+big = 1_000_000
+mask = 0xff
+enabled = true
+released = 1979-05-27T07:32:00-08:00
+day = 1979-05-27
+at = 1979-05-27 07:32:00
+alarm = 07:30:00
+ports = [ 80,
+  443, # https
+]
+site."google.com" = true
+
+[owner]
+name = 'Tom "TP" Preston'
+bio = """
+Roses are red \
+  and so on.
+Says "hi"."""
+path = '''C:\Users\tom'''
+
+[env]
+LOG_LEVEL = "debug"
+point = { x = 1, y = 2, tag.kind = "p" }
+
+[env.nested.deep]
+ok = "\u00e9\t"
+
+[[replicas]]
+name = "a"
+
+[[replicas]]
+name = "b"
+[replicas.limits]
+cpu = 2
+`
+
+func TestUnmarshal_Config(t *testing.T) {
+	got, err := Unmarshal([]byte(service))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"title":    "api",
+		"version":  json.Number("3"),
+		"ratio":    json.Number("0.75"),
+		"big":      json.Number("1000000"),
+		"mask":     json.Number("255"),
+		"enabled":  true,
+		"released": Datetime("1979-05-27T07:32:00-08:00"),
+		"day":      Datetime("1979-05-27"),
+		"at":       Datetime("1979-05-27 07:32:00"),
+		"alarm":    Datetime("07:30:00"),
+		"ports":    []any{json.Number("80"), json.Number("443")},
+		"site":     map[string]any{"google.com": true},
+		"owner": map[string]any{
+			"name": `Tom "TP" Preston`,
+			"bio":  "Roses are red and so on.\nSays \"hi\".",
+			"path": `C:\Users\tom`,
+		},
+		"env": map[string]any{
+			"LOG_LEVEL": "debug",
+			"point":     map[string]any{"x": json.Number("1"), "y": json.Number("2"), "tag": map[string]any{"kind": "p"}},
+			"nested":    map[string]any{"deep": map[string]any{"ok": "é\t"}},
+		},
+		"replicas": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b", "limits": map[string]any{"cpu": json.Number("2")}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected document.\ngot=%#v\nwant=%#v", got, want)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	for _, in := range []string{
+		"a = 1\na = 2\n",
+		"[a]\n[a]\n",
+		"a.b = 1\n[a]\n",
+		"[a.b]\n[a]\nb.c = 1\n",
+		"a = {x = 1}\n[a]\n",
+		"a = [1\n",
+		"a = \"open\n",
+		"a = 01\n",
+		"a = inf\n",
+		"a = 1979-13-01\n",
+		"a = 1 b = 2\n",
+		"[[a]]\n[a]\n",
+		"= 1\n",
+	} {
+		var se *SyntaxError
+		if _, err := Unmarshal([]byte(in)); !errors.As(err, &se) {
+			t.Fatalf("expected a syntax error for %q, got %v", in, err)
+		}
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	got, err := Unmarshal([]byte(service))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, b)
+	}
+	if !reflect.DeepEqual(again, got) {
+		t.Fatalf("unexpected round trip.\ngot=%#v\nwant=%#v\ntoml:\n%s", again, got, b)
+	}
+	b2, _ := Marshal(again)
+	if string(b2) != string(b) {
+		t.Fatalf("output is not stable:\n%s\n---\n%s", b, b2)
+	}
+
+	doc := map[string]any{
+		"strings": []any{"", "quote\"s", "back\\slash", "multi\nline", "\x01", "é"},
+		"numbers": []any{0.0, -1.5, 1e21, 1e-7, 123456789.0},
+		"mixed":   []any{map[string]any{"a": 1.0}, "x", []any{}},
+		"empty":   map[string]any{},
+		"tables":  []any{map[string]any{}, map[string]any{"k": []any{map[string]any{"z": false}}}},
+		"odd key": map[string]any{"": "empty", "a.b": 1.0},
+	}
+	if b, err = Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Unmarshal(b)
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, b)
+	}
+	// Integral float64 values are written as integers.
+	doc["numbers"] = []any{json.Number("0"), json.Number("-1.5"), json.Number("1e+21"), json.Number("1e-07"), json.Number("123456789")}
+	doc["mixed"].([]any)[0] = map[string]any{"a": json.Number("1")}
+	doc["odd key"].(map[string]any)["a.b"] = json.Number("1")
+	if !reflect.DeepEqual(got, doc) {
+		t.Fatalf("unexpected round trip.\ngot=%#v\nwant=%#v\ntoml:\n%s", got, doc, b)
+	}
+}
+
+func TestMarshal_Layout(t *testing.T) {
+	b, err := Marshal(map[string]any{
+		"servers": []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}},
+		"db":      map[string]any{"port": 5432, "pool": map[string]any{"size": 4}},
+		"name":    "svc",
+		"tags":    []any{"x", "y"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `name = "svc"
+tags = ["x", "y"]
+
+[db]
+port = 5432
+
+[db.pool]
+size = 4
+
+[[servers]]
+host = "a"
+
+[[servers]]
+host = "b"
+`
+	if string(b) != want {
+		t.Fatalf("unexpected TOML.\ngot:\n%s\nwant:\n%s", b, want)
+	}
+	if _, err := Marshal(map[string]any{"a": nil}); err == nil {
+		t.Fatal("expected an error for a null value")
+	}
+}
+
+func TestDiffPatch(t *testing.T) {
+	before := []byte(`
+name = "api"
+replicas = 2
+updated = 2024-01-01
+
+[env]
+LOG_LEVEL = "info"
+`)
+	after := []byte(`
+name = "api"
+replicas = 3
+updated = 2024-02-01
+
+[env]
+LOG_LEVEL = "debug"
+TRACE = true
+`)
+	delta, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"replicas": []any{json.Number("2"), json.Number("3")},
+		"updated":  []any{Datetime("2024-01-01"), Datetime("2024-02-01")},
+		"env": map[string]any{
+			"LOG_LEVEL": []any{"info", "debug"},
+			"TRACE":     []any{true},
+		},
+	}
+	if !reflect.DeepEqual(delta, want) {
+		t.Fatalf("unexpected delta. got=%v want=%v", delta, want)
+	}
+	got, err := Patch(before, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDoc := `name = "api"
+replicas = 3
+updated = 2024-02-01
+
+[env]
+LOG_LEVEL = "debug"
+TRACE = true
+`
+	if string(got) != wantDoc {
+		t.Fatalf("unexpected patched TOML.\ngot:\n%s\nwant:\n%s", got, wantDoc)
+	}
+
+	// Deltas are plain JSON; dates come back as strings.
+	wire, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(wire, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Patch(before, decoded); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatch_KeepsUntouchedNumbers(t *testing.T) {
+	doc := []byte("big = 9007199254740993\nf = 1.0\nn = 1\nx = 1.5e3\n")
+	got, err := Patch(doc, map[string]any{"n": []any{json.Number("1"), json.Number("2")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "big = 9007199254740993\nf = 1.0\nn = 2\nx = 1.5e3\n"
+	if string(got) != want {
+		t.Fatalf("unexpected patched TOML.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Floats and integers survive a delta shipped as JSON and decoded with
+	// UseNumber.
+	delta, err := Diff(doc, []byte("big = 9007199254740995\nf = 2.0\nn = 1\nx = 1.5e3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(wire))
+	dec.UseNumber()
+	var decoded map[string]any
+	if err := dec.Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Patch(doc, decoded); err != nil {
+		t.Fatal(err)
+	}
+	want = "big = 9007199254740995\nf = 2.0\nn = 1\nx = 1.5e3\n"
+	if string(got) != want {
+		t.Fatalf("unexpected patched TOML.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestConformance reads the documents in testdata/valid and compares them
+// with the JSON file of the same name, and checks that those in
+// testdata/invalid are rejected with a SyntaxError.
+func TestConformance(t *testing.T) {
+	valid, err := filepath.Glob("testdata/valid/*.toml")
+	if err != nil || len(valid) == 0 {
+		t.Fatalf("no valid documents: %v", err)
+	}
+	for _, path := range valid {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		wantJSON, err := os.ReadFile(strings.TrimSuffix(path, ".toml") + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, w := comparable(t, gotJSON), comparable(t, wantJSON); !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: unexpected document.\ngot=%#v\nwant=%#v", path, g, w)
+		}
+	}
+
+	invalid, err := filepath.Glob("testdata/invalid/*.toml")
+	if err != nil || len(invalid) == 0 {
+		t.Fatalf("no invalid documents: %v", err)
+	}
+	for _, path := range invalid {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var se *SyntaxError
+		if _, err := Unmarshal(data); !errors.As(err, &se) {
+			t.Errorf("%s: expected a syntax error, got %v", path, err)
+		}
+	}
+}
+
+// comparable decodes JSON with integers kept as json.Number and floats
+// turned into float64, so that 1e06 and 1000000.0 compare equal but 1 and
+// 1.0 do not.
+func comparable(t *testing.T, data []byte) any {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("%v in %s", err, data)
+	}
+	var walk func(any) any
+	walk = func(v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, x := range t {
+				t[k] = walk(x)
+			}
+		case []any:
+			for i, x := range t {
+				t[i] = walk(x)
+			}
+		case json.Number:
+			if strings.ContainsAny(string(t), ".eE") {
+				f, _ := t.Float64()
+				return f
+			}
+		}
+		return v
+	}
+	return walk(v)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte(service))
+	seeds, _ := filepath.Glob("testdata/*/*.toml")
+	for _, path := range seeds {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		b, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		got, err := Unmarshal(b)
+		if err != nil {
+			t.Fatalf("reading back %q: %v", b, err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("unexpected round trip of %q.\ngot=%#v\nwant=%#v", b, got, v)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package jsondiffgo
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// TieBreak decides which of two concurrent changes to the value at path
+// survives a Transform. a and b are the values the first and the second delta
+// produce, nil when a delta deletes the value; it reports whether a wins.
+type TieBreak func(path Pointer, a, b any) bool
+
+// FirstWins is the default TieBreak: the first delta's change survives.
+func FirstWins(Pointer, any, any) bool { return true }
+
+// SecondWins is a TieBreak under which the second delta's change survives.
+func SecondWins(Pointer, any, any) bool { return false }
+
+// TransformOption configures Transform.
+type TransformOption func(*transformer)
+
+// WithTieBreak sets the rule that settles changes both deltas make to the
+// same value. The default is FirstWins.
+func WithTieBreak(tb TieBreak) TransformOption {
+	return func(t *transformer) {
+		if tb != nil {
+			t.tieBreak = tb
+		}
+	}
+}
+
+// Transform rewrites two deltas computed against the same document so that
+// each can be applied after the other: applying d1 and then d2prime gives the
+// same document as applying d2 and then d1prime.
+//
+// Changes to different values are kept as they are. Array indices are
+// shifted over the items the other delta inserts and deletes; items inserted
+// by both deltas at the same position are ordered with those of d1 first.
+// When both deltas change the same value differently, including one deleting
+// an array item the other changes, the TieBreak decides which change
+// survives. Array moves cannot be transformed and yield ErrInvalidDelta.
+// Like Compose, it returns *OrderedMap objects of the deltas as plain maps.
+func Transform(d1, d2 map[string]any, opts ...TransformOption) (d1prime, d2prime map[string]any, err error) {
+	d1, d2 = plainDelta(d1), plainDelta(d2)
+	t := &transformer{tieBreak: FirstWins}
+	for _, opt := range opts {
+		opt(t)
+	}
+	var a, b any
+	if len(d1) > 0 {
+		a = d1
+	}
+	if len(d2) > 0 {
+		b = d2
+	}
+	ap, bp, err := t.transform(Pointer{}, a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deltaMap(ap), deltaMap(bp), nil
+}
+
+// deltaMap returns a copy of the root delta d, or an empty delta for nil.
+func deltaMap(d any) map[string]any {
+	if m, ok := deepCopy(d).(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+type transformer struct {
+	tieBreak TieBreak
+}
+
+// transform returns the delta that applies a after b, and the one that
+// applies b after a.
+func (t *transformer) transform(p Pointer, a, b any) (any, any, error) {
+	ka, kb := deltaKindOf(a), deltaKindOf(b)
+	switch {
+	case ka == kindOther || kb == kindOther:
+		return nil, nil, fmt.Errorf("%w at %s: cannot transform moves or text diffs", ErrInvalidDelta, p)
+	case ka == kindNone || kb == kindNone:
+		return a, b, nil
+	case ka == kindObject && kb == kindObject:
+		return t.transformObject(p, a.(map[string]any), b.(map[string]any))
+	case ka == kindArray && kb == kindArray:
+		return t.transformArray(p, a.(map[string]any), b.(map[string]any))
+	case deepEqual(a, b):
+		return nil, nil, nil
+	}
+
+	// Both deltas change the value. One of them records what it was.
+	var orig any
+	var exists bool
+	for _, d := range []any{a, b} {
+		switch deltaKindOf(d) {
+		case kindAdd:
+			exists = false
+		case kindReplace, kindDelete:
+			orig, exists = d.([]any)[0], true
+		default:
+			continue
+		}
+		break
+	}
+	if !exists && (ka == kindObject || ka == kindArray || kb == kindObject || kb == kindArray) {
+		return nil, nil, fmt.Errorf("%w at %s: incompatible changes", ErrInvalidDelta, p)
+	}
+	va, okA, err := changedValue(orig, exists, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	vb, okB, err := changedValue(orig, exists, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.tieBreak(p, va, vb) {
+		return transition(vb, okB, va, okA), nil, nil
+	}
+	return nil, transition(va, okA, vb, okB), nil
+}
+
+func (t *transformer) transformObject(p Pointer, a, b map[string]any) (any, any, error) {
+	ap := make(map[string]any, len(a))
+	bp := make(map[string]any, len(b))
+	// Keys are visited in sorted order so that tie-breaks, and the conflict
+	// Rebase reports, do not depend on map iteration order.
+	for _, k := range slices.Sorted(maps.Keys(a)) {
+		va := a[k]
+		x, y, err := t.transform(p.child(k), va, b[k])
+		if err != nil {
+			return nil, nil, err
+		}
+		if x != nil {
+			ap[k] = x
+		}
+		if y != nil {
+			bp[k] = y
+		}
+	}
+	for k, vb := range b {
+		if _, seen := a[k]; !seen {
+			bp[k] = vb
+		}
+	}
+	return nonEmpty(ap), nonEmpty(bp), nil
+}
+
+// transformArray resolves both deltas against the original array and merges
+// the two results in original order. Each transformed delta is then encoded
+// as a plan over the array the other delta produces.
+func (t *transformer) transformArray(p Pointer, a, b map[string]any) (any, any, error) {
+	if hasMoves(a) || hasMoves(b) {
+		return nil, nil, fmt.Errorf("%w at %s: cannot transform moves", ErrInvalidDelta, p)
+	}
+	pa, pb := newArrayPlan(), newArrayPlan()
+	if err := pa.apply(a); err != nil {
+		return nil, nil, err
+	}
+	if err := pb.apply(b); err != nil {
+		return nil, nil, err
+	}
+	end := max(pa.tail, pb.tail)
+	pa.ensure(len(pa.items) + end - pa.tail)
+	pb.ensure(len(pb.items) + end - pb.tail)
+
+	posA, newsA := anchors(pa, end)
+	posB, newsB := anchors(pb, end)
+
+	// ap applies a to the array b produced, bp applies b to the one a produced.
+	ap := &arrayPlan{tail: len(pb.items), deleted: map[int]any{}}
+	bp := &arrayPlan{tail: len(pa.items), deleted: map[int]any{}}
+	for g := 0; g <= end; g++ {
+		for _, k := range newsA[g] {
+			ap.items = append(ap.items, slot{old: -1, value: pa.items[k].value})
+			bp.items = append(bp.items, slot{old: k})
+		}
+		for _, k := range newsB[g] {
+			ap.items = append(ap.items, slot{old: k})
+			bp.items = append(bp.items, slot{old: -1, value: pb.items[k].value})
+		}
+		if g == end {
+			break
+		}
+
+		ia, inA := posA[g]
+		ib, inB := posB[g]
+		switch {
+		case inA && inB:
+			da, db, err := t.transform(p.child(strconv.Itoa(g)), pa.items[ia].delta, pb.items[ib].delta)
+			if err != nil {
+				return nil, nil, err
+			}
+			ap.items = append(ap.items, slot{old: ib, delta: da})
+			bp.items = append(bp.items, slot{old: ia, delta: db})
+		case inA:
+			// b deleted the item; a may have changed it.
+			v, err := applyDelta(pb.deleted[g], pa.items[ia].delta)
+			if err != nil {
+				return nil, nil, err
+			}
+			if pa.items[ia].delta != nil && t.tieBreak(p.child(strconv.Itoa(g)), v, nil) {
+				ap.items = append(ap.items, slot{old: -1, value: v})
+				bp.items = append(bp.items, slot{old: ia})
+			} else {
+				bp.deleted[ia] = v
+			}
+		case inB:
+			// a deleted the item; b may have changed it.
+			v, err := applyDelta(pa.deleted[g], pb.items[ib].delta)
+			if err != nil {
+				return nil, nil, err
+			}
+			if pb.items[ib].delta != nil && !t.tieBreak(p.child(strconv.Itoa(g)), nil, v) {
+				ap.items = append(ap.items, slot{old: ib})
+				bp.items = append(bp.items, slot{old: -1, value: v})
+			} else {
+				ap.deleted[ib] = v
+			}
+		}
+	}
+	return nonEmpty(ap.delta()), nonEmpty(bp.delta()), nil
+}
+
+// anchors maps the original items a plan keeps to their position, and groups
+// the items it inserts by the original item they precede; end stands for the
+// end of the array.
+func anchors(p *arrayPlan, end int) (map[int]int, map[int][]int) {
+	pos := map[int]int{}
+	news := map[int][]int{}
+	var pending []int
+	for i, s := range p.items {
+		if s.old < 0 {
+			pending = append(pending, i)
+			continue
+		}
+		pos[s.old] = i
+		news[s.old] = pending
+		pending = nil
+	}
+	news[end] = pending
+	return pos, news
+}
+
+func hasMoves(d map[string]any) bool {
+	for k, v := range d {
+		if len(k) > 1 && k[0] == '_' {
+			if a, ok := v.([]any); ok && len(a) == 3 && !isZero(a[2]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changedValue returns the value delta d makes of orig, and whether it exists.
+func changedValue(orig any, exists bool, d any) (any, bool, error) {
+	switch deltaKindOf(d) {
+	case kindNone:
+		return orig, exists, nil
+	case kindDelete:
+		return nil, false, nil
+	}
+	v, err := applyDelta(orig, d)
+	return v, err == nil, err
+}
+
+// transition returns the delta from one state of a value to another.
+func transition(from any, fromOK bool, to any, toOK bool) any {
+	switch {
+	case !fromOK && !toOK:
+		return nil
+	case !fromOK:
+		return []any{to}
+	case !toOK:
+		return []any{from, float64(0), float64(0)}
+	}
+	return replacement(from, to)
+}
+
+// nonEmpty turns an empty delta into nil.
+func nonEmpty(m map[string]any) any {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,156 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// converge applies d1 then d2prime, and d2 then d1prime, to x.
+func converge(t *testing.T, x map[string]any, d1, d2 map[string]any, opts ...TransformOption) (map[string]any, map[string]any) {
+	t.Helper()
+	d1p, d2p, err := Transform(d1, d2, opts...)
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	y1, err := Patch(x, d1)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	a, err := Patch(y1, d2p)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	y2, err := Patch(x, d2)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	b, err := Patch(y2, d1p)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	return a, b
+}
+
+func TestTransform_IndependentChanges(t *testing.T) {
+	x := map[string]any{"a": 1.0, "b": 1.0, "l": []any{"p", "q", "r"}}
+	y := map[string]any{"a": 2.0, "b": 1.0, "l": []any{"n", "p", "q", "r"}}
+	z := map[string]any{"a": 1.0, "b": 3.0, "l": []any{"p", "r", "m"}}
+
+	got, other := converge(t, x, Diff(x, y), Diff(x, z))
+	want := map[string]any{"a": 2.0, "b": 3.0, "l": []any{"n", "p", "r", "m"}}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(other, want) {
+		t.Fatalf("unexpected result. got=%v and %v want=%v", got, other, want)
+	}
+}
+
+func TestTransform_TieBreak(t *testing.T) {
+	x := map[string]any{"k": "base", "l": []any{map[string]any{"v": 1.0}, "s"}}
+	y := map[string]any{"k": "first", "l": []any{map[string]any{"v": 2.0}, "s"}}
+	z := map[string]any{"k": "second", "l": []any{"s"}}
+
+	got, other := converge(t, x, Diff(x, y), Diff(x, z))
+	want := map[string]any{"k": "first", "l": []any{map[string]any{"v": 2.0}, "s"}}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(other, want) {
+		t.Fatalf("unexpected result. got=%v and %v want=%v", got, other, want)
+	}
+
+	got, other = converge(t, x, Diff(x, y), Diff(x, z), WithTieBreak(SecondWins))
+	want = map[string]any{"k": "second", "l": []any{"s"}}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(other, want) {
+		t.Fatalf("unexpected result. got=%v and %v want=%v", got, other, want)
+	}
+}
+
+func TestTransform_CustomTieBreak(t *testing.T) {
+	x := map[string]any{"n": 5.0, "s": "a"}
+	y := map[string]any{"n": 7.0, "s": "b"}
+	z := map[string]any{"n": 9.0, "s": "c"}
+
+	var paths []string
+	larger := func(p Pointer, a, b any) bool {
+		paths = append(paths, p.String())
+		if fa, ok := a.(float64); ok {
+			return fa > b.(float64)
+		}
+		return true
+	}
+	got, _ := converge(t, x, Diff(x, y), Diff(x, z), WithTieBreak(larger))
+	want := map[string]any{"n": 9.0, "s": "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected result. got=%v want=%v", got, want)
+	}
+	if len(paths) == 0 {
+		t.Fatal("tie-break was not consulted")
+	}
+}
+
+func TestTransform_Moves(t *testing.T) {
+	d1 := map[string]any{"l": map[string]any{"_t": "a", "_0": []any{"", 1.0, 3.0}}}
+	d2 := map[string]any{"l": map[string]any{"_t": "a", "0": []any{"x"}}}
+	if _, _, err := Transform(d1, d2); !errors.Is(err, ErrInvalidDelta) {
+		t.Fatalf("expected ErrInvalidDelta, got %v", err)
+	}
+}
+
+type jsonFork struct{ Base, A, B map[string]any }
+
+// Generate implements quick.Generator with two concurrent edits of a random
+// document.
+func (jsonFork) Generate(r *rand.Rand, size int) reflect.Value {
+	base := jsonObject{}.Generate(r, size).Interface().(jsonObject).M
+	return reflect.ValueOf(jsonFork{
+		Base: base,
+		A:    mutate(r, base, 0).(map[string]any),
+		B:    mutate(r, base, 0).(map[string]any),
+	})
+}
+
+func TestProperty_Transform_Quick(t *testing.T) {
+	cfg := &quick.Config{
+		MaxCount: 300,
+		Rand:     newPseudoCryptoRand(),
+	}
+	prop := func(f jsonFork, first bool) bool {
+		d1, d2 := Diff(f.Base, f.A), Diff(f.Base, f.B)
+		tb := FirstWins
+		if !first {
+			tb = SecondWins
+		}
+		d1p, d2p, err := Transform(d1, d2, WithTieBreak(tb))
+		if err != nil {
+			t.Logf("Transform failed: %v", err)
+			return false
+		}
+		y1, err1 := Patch(f.Base, d1)
+		y2, err2 := Patch(f.Base, d2)
+		if err1 != nil || err2 != nil {
+			t.Logf("Patch failed: %v %v", err1, err2)
+			return false
+		}
+		a, err1 := Patch(y1, d2p)
+		b, err2 := Patch(y2, d1p)
+		if err1 != nil || err2 != nil {
+			t.Logf("Patch failed: %v %v", err1, err2)
+			return false
+		}
+		if !reflect.DeepEqual(a, b) {
+			bx, _ := json.Marshal(f.Base)
+			b1, _ := json.Marshal(d1)
+			b2, _ := json.Marshal(d2)
+			b1p, _ := json.Marshal(d1p)
+			b2p, _ := json.Marshal(d2p)
+			ba, _ := json.Marshal(a)
+			bb, _ := json.Marshal(b)
+			t.Logf("base=%s\nd1=%s\nd2=%s\nd1'=%s\nd2'=%s\nd1;d2'=%s\nd2;d1'=%s", bx, b1, b2, b1p, b2p, ba, bb)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, cfg); err != nil {
+		t.Fatalf("property failed: %v", err)
+	}
+}
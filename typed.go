@@ -0,0 +1,50 @@
+package jsondiffgo
+
+import "encoding/json"
+
+// Delta is a jsondiffpatch-style delta as produced by Diff. It is assignable
+// to and from map[string]any.
+type Delta map[string]any
+
+// rootKey wraps deltas for documents whose root is not an object.
+const rootKey = "_root"
+
+// DiffOf diffs two Go values of the same type by their JSON representation,
+// with the same result as DiffStructs.
+func DiffOf[T any](a, b T, opts ...Option) (Delta, error) {
+	return DiffStructs(a, b, opts...)
+}
+
+// PatchOf applies d to the JSON representation of v and decodes the result
+// into a new T. v itself is not modified. Values whose JSON form is not an
+// object, such as slices, are patched as the root of the document.
+func PatchOf[T any](v T, d Delta) (T, error) {
+	var out T
+	doc, err := toJSONValue(v)
+	if err != nil {
+		return out, err
+	}
+	obj, isObj := doc.(map[string]any)
+	if !isObj {
+		obj = map[string]any{rootKey: doc}
+		// Diff returns array deltas unwrapped and scalar changes under
+		// "_root"; bring both to the wrapped form.
+		if _, ok := d[rootKey]; !ok && len(d) > 0 {
+			d = Delta{rootKey: map[string]any(d)}
+		}
+	}
+	patched, err := Patch(obj, d)
+	if err != nil {
+		return out, err
+	}
+	var result any = patched
+	if !isObj {
+		result = patched[rootKey]
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
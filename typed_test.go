@@ -0,0 +1,56 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffOfPatchOf_Struct(t *testing.T) {
+	type doc struct {
+		Name  string         `json:"name"`
+		Tags  []string       `json:"tags"`
+		Attrs map[string]int `json:"attrs,omitempty"`
+		Next  *doc           `json:"next,omitempty"`
+	}
+	a := doc{Name: "a", Tags: []string{"x", "y"}, Attrs: map[string]int{"k": 1}}
+	b := doc{Name: "b", Tags: []string{"y", "z"}, Next: &doc{Name: "n"}}
+	d, err := DiffOf(a, b)
+	if err != nil {
+		t.Fatalf("DiffOf failed: %v", err)
+	}
+	want := Delta{
+		"name":  []any{"a", "b"},
+		"tags":  map[string]any{"_0": []any{"x", float64(0), float64(0)}, "1": []any{"z"}, "_t": "a"},
+		"attrs": []any{map[string]any{"k": float64(1)}, float64(0), float64(0)},
+		"next":  []any{map[string]any{"name": "n", "tags": nil}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected delta\n got=%v\nwant=%v", d, want)
+	}
+	got, err := PatchOf(a, d)
+	if err != nil {
+		t.Fatalf("PatchOf failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Fatalf("PatchOf mismatch: got=%+v want=%+v", got, b)
+	}
+	if a.Name != "a" || len(a.Tags) != 2 {
+		t.Fatalf("PatchOf modified its input: %+v", a)
+	}
+}
+
+func TestDiffOfPatchOf_NonObjectRoot(t *testing.T) {
+	a := []int{1, 2, 3}
+	b := []int{1, 3, 4}
+	d, err := DiffOf(a, b)
+	if err != nil {
+		t.Fatalf("DiffOf failed: %v", err)
+	}
+	got, err := PatchOf(a, d)
+	if err != nil {
+		t.Fatalf("PatchOf failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Fatalf("PatchOf mismatch: got=%v want=%v", got, b)
+	}
+}
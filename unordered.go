@@ -0,0 +1,137 @@
+package jsondiffgo
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+type unorderedArray struct {
+	pat      pathPattern
+	identity func(item any) string
+}
+
+// unorderedAt returns the multiset settings for the array at p, if any.
+func (d *differ) unorderedAt(p Pointer) (unorderedArray, bool) {
+	for _, u := range d.opts.unordered {
+		if u.pat.match(p) {
+			return u, true
+		}
+	}
+	return unorderedArray{}, false
+}
+
+// diffUnordered compares two arrays as multisets. Items are paired by
+// identity (or by equality when there is none), in order of appearance.
+// Unpaired old items are deleted at their original index and unpaired new
+// items are appended after the surviving ones, so Patch produces the old
+// order with additions at the end. Paired items that differ get a nested
+// delta at their position in the patched array; path options see them at
+// their index in l2, as when they were paired.
+func (d *differ) diffUnordered(p Pointer, u unorderedArray, l1, l2 []any) any {
+	pairOf := d.pairItems(p, u, l1, l2)
+
+	out := map[string]any{}
+	matched := make([]bool, len(l2))
+	type pair struct{ pos, old, next int }
+	var pairs []pair
+	pos := 0
+	for i, j := range pairOf {
+		if j < 0 {
+			out["_"+strconv.Itoa(i)] = []any{l1[i], float64(0), float64(0)}
+			continue
+		}
+		matched[j] = true
+		pairs = append(pairs, pair{pos: pos, old: i, next: j})
+		pos++
+	}
+
+	nested := make([]any, len(pairs))
+	d.forEach(len(pairs), func(k int) {
+		pr := pairs[k]
+		nested[k] = d.diff(d.child(p, strconv.Itoa(pr.next)), l1[pr.old], l2[pr.next])
+	})
+	for k, pr := range pairs {
+		if nested[k] != nil {
+			out[strconv.Itoa(pr.pos)] = nested[k]
+		}
+	}
+
+	for j, v := range l2 {
+		if !matched[j] {
+			out[strconv.Itoa(pos)] = []any{v}
+			pos++
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	out["_t"] = "a"
+	return out
+}
+
+// equalUnordered reports whether two arrays of the same length hold equal
+// items regardless of order.
+func (d *differ) equalUnordered(p Pointer, u unorderedArray, l1, l2 []any) bool {
+	for i, j := range d.pairItems(p, u, l1, l2) {
+		if j < 0 || !d.equal(d.child(p, strconv.Itoa(j)), l1[i], l2[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pairItems returns, for every index of l1, the index of its partner in l2
+// or -1 when it has none.
+func (d *differ) pairItems(p Pointer, u unorderedArray, l1, l2 []any) []int {
+	pairOf := make([]int, len(l1))
+	identity := u.identity
+	if identity == nil {
+		identity = canonicalJSON
+	}
+	free := map[string][]int{}
+	for j, v := range l2 {
+		id := identity(v)
+		free[id] = append(free[id], j)
+	}
+	used := make([]bool, len(l2))
+	for i, v := range l1 {
+		pairOf[i] = -1
+		id := identity(v)
+		if js := free[id]; len(js) > 0 {
+			pairOf[i] = js[0]
+			used[js[0]] = true
+			free[id] = js[1:]
+		}
+	}
+	if u.identity != nil || !d.customEqual {
+		return pairOf
+	}
+
+	// Other options may make items with different encodings equal, so the
+	// leftovers are compared pairwise.
+	for i, v := range l1 {
+		if pairOf[i] >= 0 {
+			continue
+		}
+		for j, w := range l2 {
+			if !used[j] && d.equal(d.child(p, strconv.Itoa(j)), v, w) {
+				pairOf[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+	return pairOf
+}
+
+// canonicalJSON identifies a value by its JSON encoding, which sorts object
+// keys and therefore does not depend on map iteration order. *OrderedMap
+// objects are encoded as maps, so they share the identity of equal maps.
+func canonicalJSON(v any) string {
+	b, err := json.Marshal(plainObjects(v))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
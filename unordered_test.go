@@ -0,0 +1,88 @@
+package jsondiffgo
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestUnordered_ReorderOnly(t *testing.T) {
+	a := parseJSON(t, `{"tags":["a","b","c"],"roles":[{"n":1},{"n":2}]}`)
+	b := parseJSON(t, `{"tags":["c","a","b"],"roles":[{"n":2},{"n":1}]}`)
+	if got := Diff(a, b, UnorderedArrays("/tags", "/roles")); len(got) != 0 {
+		t.Fatalf("expected empty diff, got=%v", got)
+	}
+}
+
+func TestUnordered_AddRemove(t *testing.T) {
+	a := parseJSON(t, `{"tags":["a","b","c","b"]}`).(map[string]any)
+	b := parseJSON(t, `{"tags":["c","d","b","a"]}`).(map[string]any)
+	want := parseJSON(t, `{"tags":{"_3":["b",0,0],"3":["d"],"_t":"a"}}`)
+	d := Diff(a, b, UnorderedArrays("/tags"))
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", d, want)
+	}
+	p, err := Patch(a, d)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if got := p["tags"]; !reflect.DeepEqual(got, []any{"a", "b", "c", "d"}) {
+		t.Fatalf("unexpected patch result: %v", got)
+	}
+}
+
+func TestUnordered_IdentityPairsObjects(t *testing.T) {
+	a := parseJSON(t, `{"users":[{"id":1,"role":"x"},{"id":2,"role":"y"},{"id":3}]}`).(map[string]any)
+	b := parseJSON(t, `{"users":[{"id":4},{"id":2,"role":"z"},{"id":1,"role":"x"}]}`).(map[string]any)
+	byID := func(v any) string { return canonicalJSON(asMap(v)["id"]) }
+	want := parseJSON(t, `{"users":{"1":{"role":["y","z"]},"_2":[{"id":3},0,0],"2":[{"id":4}],"_t":"a"}}`)
+	d := Diff(a, b, UnorderedArrayBy("/users", byID))
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", d, want)
+	}
+	p, err := Patch(a, d)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if !sameMultiset(p["users"].([]any), b["users"].([]any)) {
+		t.Fatalf("patched users %v do not match %v", p["users"], b["users"])
+	}
+}
+
+func TestUnordered_NestedInOrderedArray(t *testing.T) {
+	// Users only differ by the order of their roles, so the ordered users
+	// array must still align them as equal.
+	a := parseJSON(t, `{"users":[{"roles":["r","w"]},{"roles":["x"]}]}`)
+	b := parseJSON(t, `{"users":[{"roles":["new"]},{"roles":["w","r"]},{"roles":["x"]}]}`)
+	want := parseJSON(t, `{"users":{"0":[{"roles":["new"]}],"_t":"a"}}`)
+	got := Diff(a, b, UnorderedArrays("/users/*/roles"))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected diff. got=%v want=%v", got, want)
+	}
+}
+
+func TestUnordered_NestedPathsUseNewIndex(t *testing.T) {
+	// The object is paired with index 1 of b, so its nested diff must be
+	// taken at /tags/1 like the comparison that paired it, not at index 0
+	// where the delta patches it.
+	a := parseJSON(t, `{"tags":[{"x":1,"y":1},"k"]}`)
+	b := parseJSON(t, `{"tags":["k",{"x":2,"y":1}]}`)
+	if got := Diff(a, b, UnorderedArrays("/tags"), IgnorePaths("/tags/1/x")); len(got) != 0 {
+		t.Fatalf("expected empty diff, got=%v", got)
+	}
+}
+
+func sameMultiset(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	enc := func(l []any) []string {
+		out := make([]string, len(l))
+		for i, v := range l {
+			out[i] = canonicalJSON(v)
+		}
+		sort.Strings(out)
+		return out
+	}
+	return reflect.DeepEqual(enc(a), enc(b))
+}
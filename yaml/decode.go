@@ -0,0 +1,998 @@
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError reports malformed or unsupported YAML.
+type SyntaxError struct {
+	Line int // 1-based
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("yaml: line %d: %s", e.Line, e.Msg)
+}
+
+// maxAliasNodes bounds the number of values copied by alias expansion, so that
+// small documents cannot expand into huge ones.
+const maxAliasNodes = 1_000_000
+
+// Unmarshal parses a YAML document into the value model of encoding/json:
+// mappings become map[string]any, sequences []any, and scalars string,
+// json.Number, bool or nil following the YAML 1.2 core schema. Mapping keys
+// are kept as written, so the key 1 becomes "1".
+//
+// Numbers are json.Number values, so that they keep their type and
+// precision: integers are written in decimal, as in 16 for 0x10, and floats
+// keep a fraction or an exponent, as in 1.0. Both are brought to JSON syntax,
+// so +.5 becomes 0.5.
+//
+// Block and flow collections, all scalar styles, anchors, aliases and the
+// standard tags are supported. Multiple documents, complex keys and values
+// with no JSON equivalent, such as .inf and .nan, are rejected.
+func Unmarshal(data []byte) (any, error) {
+	s := strings.TrimPrefix(string(data), "\ufeff")
+	if !utf8.ValidString(s) {
+		return nil, &SyntaxError{Line: 1, Msg: "invalid UTF-8"}
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	p := &parser{lines: lines, anchors: map[string]any{}}
+	return p.document()
+}
+
+type parser struct {
+	lines   []string
+	line    int // current line
+	col     int // byte offset in the current line
+	anchors map[string]any
+	copied  int // values copied for aliases so far
+}
+
+// plainText is a plain scalar that has not been resolved yet, because a tag
+// may still ask for it to be kept as a string.
+type plainText string
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := p.line + 1
+	if line > len(p.lines) {
+		line = len(p.lines)
+	}
+	return &SyntaxError{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool { return p.line >= len(p.lines) }
+
+// rest returns the unread part of the current line.
+func (p *parser) rest() string {
+	if p.eof() {
+		return ""
+	}
+	return p.lines[p.line][p.col:]
+}
+
+func (p *parser) skipSpaces() {
+	s := p.rest()
+	p.col += len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+// atLineEnd reports whether only whitespace or a comment is left on the line.
+func (p *parser) atLineEnd() bool {
+	s := strings.TrimLeft(p.rest(), " \t")
+	if s == "" {
+		return true
+	}
+	i := len(p.lines[p.line]) - len(s)
+	return s[0] == '#' && (i == 0 || p.lines[p.line][i-1] == ' ' || p.lines[p.line][i-1] == '\t')
+}
+
+func (p *parser) newline() {
+	p.line++
+	p.col = 0
+}
+
+// endLine checks that nothing but a comment follows a node and moves to the
+// next line.
+func (p *parser) endLine() error {
+	if !p.atLineEnd() {
+		p.skipSpaces()
+		if strings.HasPrefix(p.rest(), ":") {
+			return p.errorf("mapping values are not allowed here")
+		}
+		return p.errorf("unexpected %q", p.rest())
+	}
+	p.newline()
+	return nil
+}
+
+func isBlank(s string) bool { return strings.TrimLeft(s, " \t") == "" }
+
+func isDocMarker(s string) bool {
+	if !strings.HasPrefix(s, "---") && !strings.HasPrefix(s, "...") {
+		return false
+	}
+	return len(s) == 3 || s[3] == ' ' || s[3] == '\t'
+}
+
+func isSeqEntry(s string) bool {
+	return strings.HasPrefix(s, "-") && (len(s) == 1 || s[1] == ' ' || s[1] == '\t')
+}
+
+func indentOf(s string) int { return len(s) - len(strings.TrimLeft(s, " ")) }
+
+// nextContent moves to the first line, starting with the current one, that
+// holds more than whitespace or a comment. It reports false at the end of the
+// document.
+func (p *parser) nextContent() bool {
+	for p.col = 0; !p.eof(); p.line++ {
+		s := p.lines[p.line]
+		if isDocMarker(s) {
+			return false
+		}
+		if t := strings.TrimLeft(s, " \t"); t != "" && t[0] != '#' {
+			return true
+		}
+	}
+	return false
+}
+
+// lineIndent returns the indentation of the current line.
+func (p *parser) lineIndent() (int, error) {
+	s := p.lines[p.line]
+	i := indentOf(s)
+	if i < len(s) && s[i] == '\t' {
+		return 0, p.errorf("tabs are not allowed in indentation")
+	}
+	return i, nil
+}
+
+func (p *parser) document() (any, error) {
+	for p.nextContent() && strings.HasPrefix(p.lines[p.line], "%") {
+		p.newline() // directives such as %YAML 1.2
+	}
+	if p.atMarker("---") {
+		p.col = 3
+	}
+	var v any
+	if !p.eof() && !p.atMarker("...") {
+		var err error
+		if v, err = p.value(-1, p.col == 0); err != nil {
+			return nil, err
+		}
+	}
+	if p.nextContent() {
+		return nil, p.errorf("unexpected content")
+	}
+	if p.atMarker("...") {
+		p.newline()
+		if p.nextContent() {
+			return nil, p.errorf("unexpected content after document end")
+		}
+	}
+	if !p.eof() {
+		return nil, p.errorf("multiple documents are not supported")
+	}
+	return v, nil
+}
+
+// atMarker reports whether the current line is the document marker m.
+func (p *parser) atMarker(m string) bool {
+	return !p.eof() && isDocMarker(p.lines[p.line]) && strings.HasPrefix(p.lines[p.line], m)
+}
+
+// value parses the node that starts at the cursor or, when only a comment is
+// left on the line, on the following lines indented more than indent. When
+// compact is set the node may be a block collection starting at the cursor.
+func (p *parser) value(indent int, compact bool) (any, error) {
+	p.skipSpaces()
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if p.atLineEnd() {
+		p.newline()
+		if !p.nextContent() {
+			return p.finish(nil, anchor, tag)
+		}
+		i, err := p.lineIndent()
+		if err != nil {
+			return nil, err
+		}
+		if i <= indent {
+			return p.finish(nil, anchor, tag)
+		}
+		p.col = i
+		compact = true
+	}
+	if v, err = p.content(indent, compact); err != nil {
+		return nil, err
+	}
+	return p.finish(v, anchor, tag)
+}
+
+func (p *parser) content(indent int, compact bool) (any, error) {
+	s := p.rest()
+	switch {
+	case isSeqEntry(s):
+		if !compact {
+			return nil, p.errorf("block sequence entries are not allowed here")
+		}
+		return p.sequence(p.col)
+	case strings.HasPrefix(s, "? ") || s == "?":
+		return nil, p.errorf("complex mapping keys are not supported")
+	}
+	if compact {
+		if _, _, ok, err := p.mappingKey(); err != nil {
+			return nil, err
+		} else if ok {
+			return p.mapping(p.col)
+		}
+	}
+	var v any
+	var err error
+	switch s[0] {
+	case '[', '{':
+		v, err = p.flowNode()
+	case '|', '>':
+		return p.blockScalar(indent)
+	case '*':
+		v, err = p.alias()
+	case '"', '\'':
+		v, err = p.quoted()
+	case '@', '`', '%':
+		return nil, p.errorf("reserved indicator %q", s[0])
+	default:
+		return p.plain(indent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, p.endLine()
+}
+
+// finish applies a node's tag and records its anchor.
+func (p *parser) finish(v any, anchor, tag string) (any, error) {
+	v, err := p.resolve(v, tag)
+	if err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+	return v, nil
+}
+
+func (p *parser) properties() (anchor, tag string, err error) {
+	for {
+		s := p.rest()
+		switch {
+		case strings.HasPrefix(s, "&") && anchor == "":
+			if anchor = nodeName(s[1:]); anchor == "" {
+				return "", "", p.errorf("missing anchor name")
+			}
+			p.col += 1 + len(anchor)
+		case strings.HasPrefix(s, "!") && tag == "":
+			tag = s[:strings.IndexAny(s+" ", " \t")]
+			p.col += len(tag)
+		default:
+			return anchor, tag, nil
+		}
+		p.skipSpaces()
+	}
+}
+
+// nodeName returns the anchor or alias name at the start of s.
+func nodeName(s string) string {
+	if i := strings.IndexAny(s, " \t,[]{}"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func (p *parser) alias() (any, error) {
+	name := nodeName(p.rest()[1:])
+	v, ok := p.anchors[name]
+	if !ok {
+		return nil, p.errorf("unknown anchor %q", name)
+	}
+	p.col += 1 + len(name)
+	return p.copyValue(v)
+}
+
+// copyValue copies an anchored value so that aliases never share memory.
+func (p *parser) copyValue(v any) (any, error) {
+	if p.copied++; p.copied > maxAliasNodes {
+		return nil, p.errorf("aliases expand to too many values")
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			c, err := p.copyValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			c, err := p.copyValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func (p *parser) sequence(indent int) ([]any, error) {
+	out := []any{}
+	for {
+		p.col = indent + 1
+		v, err := p.value(indent, true)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		if !p.nextContent() {
+			return out, nil
+		}
+		i, err := p.lineIndent()
+		if err != nil {
+			return nil, err
+		}
+		if i > indent {
+			return nil, p.errorf("bad indentation of a sequence entry")
+		}
+		if i < indent || !isSeqEntry(p.lines[p.line][i:]) {
+			return out, nil
+		}
+	}
+}
+
+func (p *parser) mapping(indent int) (map[string]any, error) {
+	out := map[string]any{}
+	for {
+		p.col = indent
+		key, end, ok, err := p.mappingKey()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorf("expected a mapping key")
+		}
+		if _, dup := out[key]; dup {
+			return nil, p.errorf("duplicate mapping key %q", key)
+		}
+		p.col = end
+		var v any
+		if p.atLineEnd() && p.sequenceFollows(indent) {
+			p.newline()
+			p.nextContent()
+			v, err = p.sequence(indent)
+		} else {
+			v, err = p.value(indent, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+		if !p.nextContent() {
+			return out, nil
+		}
+		i, err := p.lineIndent()
+		if err != nil {
+			return nil, err
+		}
+		if i > indent {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		if i < indent || isSeqEntry(p.lines[p.line][i:]) {
+			return out, nil
+		}
+	}
+}
+
+// sequenceFollows reports whether the next content line is a sequence entry
+// at indent, which YAML allows as the value of a mapping entry at indent.
+func (p *parser) sequenceFollows(indent int) bool {
+	for j := p.line + 1; j < len(p.lines); j++ {
+		s := p.lines[j]
+		if t := strings.TrimLeft(s, " \t"); t == "" || t[0] == '#' {
+			continue
+		}
+		return !isDocMarker(s) && indentOf(s) == indent && isSeqEntry(s[indent:])
+	}
+	return false
+}
+
+// mappingKey reports whether the cursor is at an implicit mapping key and
+// returns the key and the offset just after its colon.
+func (p *parser) mappingKey() (key string, end int, ok bool, err error) {
+	s := p.rest()
+	if s == "" {
+		return "", 0, false, nil
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		line, col := p.line, p.col
+		defer func() { p.line, p.col = line, col }()
+		k, err := p.quoted()
+		if err != nil || p.line != line {
+			return "", 0, false, nil
+		}
+		p.skipSpaces()
+		if r := p.rest(); strings.HasPrefix(r, ":") && (len(r) == 1 || r[1] == ' ' || r[1] == '\t') {
+			return k, p.col + 1, true, nil
+		}
+		return "", 0, false, nil
+	}
+	if strings.ContainsRune("[]{},#&*!|>%@`-?:", rune(s[0])) && !(strings.ContainsRune("-?:", rune(s[0])) && len(s) > 1 && s[1] != ' ' && s[1] != '\t') {
+		return "", 0, false, nil
+	}
+	text, n := plainLine(s, false)
+	if r := s[n:]; strings.HasPrefix(r, ":") && text != "" {
+		return text, p.col + n + 1, true, nil
+	}
+	return "", 0, false, nil
+}
+
+// plainLine returns the plain scalar at the start of s and the offset where
+// it stops: before ": ", " #" or, in flow context, a flow indicator.
+func plainLine(s string, flow bool) (string, int) {
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t' || flow && strings.IndexByte(",[]{}", s[i+1]) >= 0) {
+			break
+		}
+		if c == '#' && i > 0 && (s[i-1] == ' ' || s[i-1] == '\t') {
+			break
+		}
+		if flow && strings.IndexByte(",[]{}", c) >= 0 {
+			break
+		}
+	}
+	return strings.TrimRight(s[:i], " \t"), i
+}
+
+// plain parses a plain scalar in block context; it continues on following
+// lines indented more than indent.
+func (p *parser) plain(indent int) (any, error) {
+	text, n := plainLine(p.rest(), false)
+	p.col += n
+	var b strings.Builder
+	b.WriteString(text)
+	for p.rest() == "" || isBlank(p.rest()) {
+		j, empty := p.line+1, 0
+		for j < len(p.lines) && isBlank(p.lines[j]) {
+			j, empty = j+1, empty+1
+		}
+		if j == len(p.lines) || isDocMarker(p.lines[j]) || indentOf(p.lines[j]) <= indent {
+			break
+		}
+		t := strings.TrimLeft(p.lines[j], " \t")
+		if t[0] == '#' {
+			break
+		}
+		if empty == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(strings.Repeat("\n", empty))
+		}
+		text, n := plainLine(t, false)
+		b.WriteString(text)
+		p.line, p.col = j, len(p.lines[j])-len(t)+n
+	}
+	return plainText(b.String()), p.endLine()
+}
+
+func (p *parser) quoted() (string, error) {
+	s := p.rest()
+	q := s[0]
+	p.col++
+	var buf []byte
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated quoted scalar")
+		}
+		s = p.lines[p.line]
+		keep := len(buf) // content that line folding must not trim
+		escapedBreak := false
+	line:
+		for p.col < len(s) {
+			c := s[p.col]
+			switch {
+			case c == q && q == '\'' && strings.HasPrefix(s[p.col:], "''"):
+				buf = append(buf, '\'')
+				p.col += 2
+			case c == q:
+				p.col++
+				return string(buf), nil
+			case c == '\\' && q == '"':
+				if p.col+1 == len(s) {
+					escapedBreak = true
+					p.col++
+					break line
+				}
+				r, n, err := unescape(s[p.col+1:])
+				if err != nil {
+					return "", p.errorf("%v", err)
+				}
+				buf = utf8.AppendRune(buf, r)
+				p.col += 1 + n
+			default:
+				buf = append(buf, c)
+				p.col++
+			}
+			if c != ' ' && c != '\t' {
+				keep = len(buf)
+			}
+		}
+		if !escapedBreak {
+			buf = buf[:keep]
+		}
+		p.newline()
+		empty := 0
+		for !p.eof() && isBlank(p.lines[p.line]) {
+			p.newline()
+			empty++
+		}
+		if !p.eof() && isDocMarker(p.lines[p.line]) {
+			return "", p.errorf("unterminated quoted scalar")
+		}
+		switch {
+		case empty > 0:
+			buf = append(buf, strings.Repeat("\n", empty)...)
+		case !escapedBreak:
+			buf = append(buf, ' ')
+		}
+		p.skipSpaces()
+	}
+}
+
+// unescape decodes the escape sequence at the start of s, which follows a
+// backslash, and returns its rune and length.
+func unescape(s string) (rune, int, error) {
+	simple := map[byte]rune{
+		'0': 0, 'a': '\a', 'b': '\b', 't': '\t', '\t': '\t', 'n': '\n', 'v': '\v', 'f': '\f',
+		'r': '\r', 'e': 0x1b, ' ': ' ', '"': '"', '/': '/', '\\': '\\',
+		'N': 0x85, '_': 0xa0, 'L': 0x2028, 'P': 0x2029,
+	}
+	if r, ok := simple[s[0]]; ok {
+		return r, 1, nil
+	}
+	size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[0]]
+	if size == 0 {
+		return 0, 0, fmt.Errorf("invalid escape \\%c", s[0])
+	}
+	if len(s) < 1+size {
+		return 0, 0, fmt.Errorf("truncated escape \\%s", s)
+	}
+	n, err := strconv.ParseUint(s[1:1+size], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, 0, fmt.Errorf("invalid escape \\%s", s[:1+size])
+	}
+	return rune(n), 1 + size, nil
+}
+
+func (p *parser) blockScalar(indent int) (any, error) {
+	header := p.rest()
+	literal := header[0] == '|'
+	explicit, chomp, i := 0, byte(0), 1
+indicators:
+	for ; i < len(header) && i < 3; i++ {
+		switch c := header[i]; {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			break indicators
+		}
+	}
+	p.col += i
+	if !p.atLineEnd() || i < len(header) && header[i] != ' ' && header[i] != '\t' {
+		return nil, p.errorf("invalid block scalar header %q", header)
+	}
+	p.newline()
+
+	n := indent + explicit
+	if explicit == 0 {
+		n = indent + 1
+		for j := p.line; j < len(p.lines); j++ {
+			if !isBlank(p.lines[j]) {
+				if i := indentOf(p.lines[j]); i > indent {
+					n = i
+				}
+				break
+			}
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	var lines []string
+	for ; !p.eof(); p.line++ {
+		s := p.lines[p.line]
+		if strings.Trim(s, " ") == "" {
+			if len(s) > n {
+				s = s[n:]
+			} else {
+				s = ""
+			}
+			lines = append(lines, s)
+			continue
+		}
+		if indentOf(s) < n || n == 0 && isDocMarker(s) {
+			break
+		}
+		lines = append(lines, s[n:])
+	}
+	p.col = 0
+
+	k := len(lines)
+	for k > 0 && lines[k-1] == "" {
+		k--
+	}
+	body, trailing := lines[:k], len(lines)-k
+	var text string
+	if literal {
+		text = strings.Join(body, "\n")
+	} else {
+		text = fold(body)
+	}
+	switch {
+	case len(body) == 0 && chomp == '+':
+		text = strings.Repeat("\n", trailing)
+	case len(body) == 0 || chomp == '-':
+	case chomp == '+':
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		text += "\n"
+	}
+	return text, nil
+}
+
+// fold joins the lines of a folded block scalar: line breaks between lines of
+// text become spaces, while empty and more-indented lines keep theirs.
+func fold(lines []string) string {
+	var b strings.Builder
+	empty, prevMore := 0, false
+	for i, l := range lines {
+		if l == "" {
+			empty++
+			continue
+		}
+		more := l[0] == ' ' || l[0] == '\t'
+		switch {
+		case i == empty:
+			b.WriteString(strings.Repeat("\n", empty))
+		case more || prevMore:
+			b.WriteString(strings.Repeat("\n", empty+1))
+		case empty == 0:
+			b.WriteByte(' ')
+		default:
+			b.WriteString(strings.Repeat("\n", empty))
+		}
+		b.WriteString(l)
+		empty, prevMore = 0, more
+	}
+	return b.String()
+}
+
+// flowSpace skips whitespace, line breaks and comments inside a flow
+// collection.
+func (p *parser) flowSpace() error {
+	for {
+		if p.eof() {
+			return p.errorf("unterminated flow collection")
+		}
+		p.skipSpaces()
+		if s := p.rest(); s != "" && (s[0] != '#' || p.col > 0 && p.lines[p.line][p.col-1] != ' ' && p.lines[p.line][p.col-1] != '\t') {
+			return nil
+		}
+		p.newline()
+	}
+}
+
+func (p *parser) flowNode() (any, error) {
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	switch s := p.rest(); {
+	case strings.HasPrefix(s, "["):
+		v, err = p.flowSequence()
+	case strings.HasPrefix(s, "{"):
+		v, err = p.flowMapping()
+	case strings.HasPrefix(s, "*"):
+		v, err = p.alias()
+	case strings.HasPrefix(s, "\""), strings.HasPrefix(s, "'"):
+		v, err = p.quoted()
+	default:
+		var text string
+		text, err = p.flowPlain()
+		v = plainText(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.finish(v, anchor, tag)
+}
+
+func (p *parser) flowPlain() (string, error) {
+	s := p.rest()
+	if s == "" || strings.IndexByte(",[]{}#&*!|>'\"%@`", s[0]) >= 0 {
+		return "", p.errorf("unexpected %q in flow collection", s)
+	}
+	text, n := plainLine(s, true)
+	p.col += n
+	return text, nil
+}
+
+// flowKey parses the key of a flow mapping entry, keeping plain keys as
+// written.
+func (p *parser) flowKey() (string, error) {
+	if s := p.rest(); strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		return p.quoted()
+	}
+	return p.flowPlain()
+}
+
+func (p *parser) flowSequence() ([]any, error) {
+	p.col++
+	out := []any{}
+	for {
+		if err := p.flowSpace(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p.rest(), "]") {
+			p.col++
+			return out, nil
+		}
+		v, err := p.flowNode()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.flowSpace(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p.rest(), ":") {
+			// A single pair, [k: v], is a one-entry mapping.
+			key, ok := v.(string)
+			if !ok {
+				return nil, p.errorf("flow mapping keys must be scalars")
+			}
+			p.col++
+			if v, err = p.flowValue(); err != nil {
+				return nil, err
+			}
+			v = map[string]any{key: v}
+		}
+		out = append(out, v)
+		if err := p.flowNext(']'); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p.rest(), "]") {
+			p.col++
+			return out, nil
+		}
+	}
+}
+
+func (p *parser) flowMapping() (map[string]any, error) {
+	p.col++
+	out := map[string]any{}
+	for {
+		if err := p.flowSpace(); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p.rest(), "}") {
+			p.col++
+			return out, nil
+		}
+		key, err := p.flowKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := out[key]; dup {
+			return nil, p.errorf("duplicate mapping key %q", key)
+		}
+		if err := p.flowSpace(); err != nil {
+			return nil, err
+		}
+		var v any
+		if strings.HasPrefix(p.rest(), ":") {
+			p.col++
+			if v, err = p.flowValue(); err != nil {
+				return nil, err
+			}
+		}
+		out[key] = v
+		if err := p.flowNext('}'); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(p.rest(), "}") {
+			p.col++
+			return out, nil
+		}
+	}
+}
+
+// flowValue parses the value after a colon in a flow collection, which may
+// be empty.
+func (p *parser) flowValue() (any, error) {
+	if err := p.flowSpace(); err != nil {
+		return nil, err
+	}
+	if s := p.rest(); s[0] == ',' || s[0] == '}' || s[0] == ']' {
+		return nil, nil
+	}
+	return p.flowNode()
+}
+
+// flowNext consumes the comma after a flow entry, leaving the cursor at the
+// next entry or at the closing bracket.
+func (p *parser) flowNext(closing byte) error {
+	if err := p.flowSpace(); err != nil {
+		return err
+	}
+	switch s := p.rest(); s[0] {
+	case ',':
+		p.col++
+		return nil
+	case closing:
+		return nil
+	}
+	return p.errorf("expected ',' or %q in flow collection", closing)
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	floatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	infNaN       = regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+)
+
+// resolve turns plain scalars into typed values and applies an explicit tag.
+func (p *parser) resolve(v any, tag string) (any, error) {
+	if tag != "" && tag != "!" && !strings.HasPrefix(tag, "!!") {
+		return nil, p.errorf("unsupported tag %s", tag)
+	}
+	text, plain := v.(plainText)
+	if plain {
+		if tag == "!" || tag == "!!str" {
+			return string(text), nil
+		}
+		var err error
+		if v, err = resolveScalar(string(text)); err != nil {
+			return nil, p.errorf("%v", err)
+		}
+	}
+	switch tag {
+	case "!!int", "!!float", "!!bool", "!!null":
+		// A quoted scalar with one of these tags is read as a plain one.
+		if s, isStr := v.(string); isStr && !plain {
+			var err error
+			if v, err = resolveScalar(s); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		}
+	}
+	ok := true
+	switch tag {
+	case "", "!":
+	case "!!str":
+		_, ok = v.(string)
+		ok = ok || v == nil
+		if v == nil {
+			v = ""
+		}
+	case "!!int":
+		n, isNum := v.(json.Number)
+		ok = isNum && !isFloat(n)
+	case "!!float":
+		var n json.Number
+		if n, ok = v.(json.Number); ok && !isFloat(n) {
+			v = n + ".0"
+		}
+	case "!!bool":
+		_, ok = v.(bool)
+	case "!!null":
+		ok = v == nil
+	case "!!map":
+		_, ok = v.(map[string]any)
+	case "!!seq":
+		_, ok = v.([]any)
+	default:
+		return nil, p.errorf("unsupported tag %s", tag)
+	}
+	if !ok {
+		return nil, p.errorf("value does not match tag %s", tag)
+	}
+	return v, nil
+}
+
+// resolveScalar resolves a plain scalar with the YAML 1.2 core schema.
+func resolveScalar(s string) (any, error) {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	switch {
+	case intPattern.MatchString(s):
+		return json.Number(decimal(s)), nil
+	case strings.HasPrefix(s, "0x") && len(s) > 2, strings.HasPrefix(s, "0o") && len(s) > 2:
+		base := map[byte]int{'x': 16, 'o': 8}[s[1]]
+		n, err := strconv.ParseUint(s[2:], base, 64)
+		if err != nil {
+			return s, nil
+		}
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	case floatPattern.MatchString(s):
+		if f, err := strconv.ParseFloat(s, 64); err != nil || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("number %s is out of range", s)
+		}
+		return json.Number(floatText(s)), nil
+	case infNaN.MatchString(s):
+		return nil, fmt.Errorf("%s has no JSON equivalent", s)
+	}
+	return s, nil
+}
+
+// decimal returns the integer s without a plus sign or leading zeros.
+func decimal(s string) string {
+	sign := ""
+	if s[0] == '-' || s[0] == '+' {
+		sign, s = strings.TrimPrefix(s[:1], "+"), s[1:]
+	}
+	if s = strings.TrimLeft(s, "0"); s == "" {
+		s = "0"
+	}
+	return sign + s
+}
+
+// floatText returns the float s in JSON syntax, with digits on both sides
+// of the point.
+func floatText(s string) string {
+	mant, exp := s, ""
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mant, exp = s[:i], s[i:]
+	}
+	whole, frac, dot := strings.Cut(mant, ".")
+	if whole == "" || whole == "+" || whole == "-" {
+		whole += "0"
+	}
+	whole = decimal(whole)
+	if dot && frac == "" {
+		frac = "0"
+	}
+	if dot {
+		return whole + "." + frac + exp
+	}
+	return whole + exp
+}
+
+// isFloat reports whether n is written as a float.
+func isFloat(n json.Number) bool {
+	return strings.ContainsAny(string(n), ".eE")
+}
@@ -0,0 +1,287 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Marshal writes v as a block-style YAML document. Mapping keys are sorted,
+// so equal values always produce the same bytes, and strings are quoted
+// whenever they would otherwise read back as another type.
+//
+// v is expected to hold the value model of encoding/json; other values are
+// converted through their JSON encoding first. A json.Number, as returned by
+// Unmarshal, is written as it is, so 1.0 stays a float. A float64 is what
+// encoding/json decodes every JSON number to, so it is written like
+// encoding/json writes it, and 1.0 becomes 1; decode deltas with
+// json.Decoder.UseNumber to keep the difference.
+func Marshal(v any) ([]byte, error) {
+	v, err := jsonValue(v)
+	if err != nil {
+		return nil, err
+	}
+	e := &encoder{}
+	if err := e.document(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// jsonValue converts v to the value model of encoding/json.
+func jsonValue(v any) (any, error) {
+	switch t := v.(type) {
+	case nil, bool, string, float64, int, int64, json.Number:
+		return v, nil
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			c, err := jsonValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = c
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			c, err := jsonValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("yaml: %w", err)
+	}
+	var out any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&out); err != nil {
+		return nil, fmt.Errorf("yaml: %w", err)
+	}
+	return out, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) document(v any) error {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) > 0 {
+			return e.mapping(t, 0, false)
+		}
+	case []any:
+		if len(t) > 0 {
+			return e.sequence(t, 0, false)
+		}
+	}
+	if err := e.scalar(v); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, '\n')
+	return nil
+}
+
+func (e *encoder) indent(n int) {
+	for i := 0; i < n; i++ {
+		e.buf = append(e.buf, ' ')
+	}
+}
+
+// mapping writes the entries of m at indent. When inline is set the first
+// entry continues the current line, after a sequence dash.
+func (e *encoder) mapping(m map[string]any, indent int, inline bool) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i > 0 || !inline {
+			e.indent(indent)
+		}
+		e.str(k)
+		e.buf = append(e.buf, ':')
+		switch t := m[k].(type) {
+		case map[string]any:
+			if len(t) > 0 {
+				e.buf = append(e.buf, '\n')
+				if err := e.mapping(t, indent+2, false); err != nil {
+					return err
+				}
+				continue
+			}
+		case []any:
+			if len(t) > 0 {
+				e.buf = append(e.buf, '\n')
+				if err := e.sequence(t, indent+2, false); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		e.buf = append(e.buf, ' ')
+		if err := e.scalar(m[k]); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, '\n')
+	}
+	return nil
+}
+
+// sequence writes the items of l at indent, like mapping.
+func (e *encoder) sequence(l []any, indent int, inline bool) error {
+	for i, x := range l {
+		if i > 0 || !inline {
+			e.indent(indent)
+		}
+		e.buf = append(e.buf, "- "...)
+		switch t := x.(type) {
+		case map[string]any:
+			if len(t) > 0 {
+				if err := e.mapping(t, indent+2, true); err != nil {
+					return err
+				}
+				continue
+			}
+		case []any:
+			if len(t) > 0 {
+				if err := e.sequence(t, indent+2, true); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := e.scalar(x); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, '\n')
+	}
+	return nil
+}
+
+// scalar writes a scalar or an empty collection.
+func (e *encoder) scalar(v any) error {
+	switch t := v.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, t)
+	case float64:
+		if math.IsInf(t, 0) || math.IsNaN(t) {
+			return fmt.Errorf("yaml: unsupported number %v", t)
+		}
+		e.buf = appendNumber(e.buf, t)
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, t, 10)
+	case json.Number:
+		if !jsonNumberPattern.MatchString(string(t)) {
+			return fmt.Errorf("yaml: invalid number %q", string(t))
+		}
+		e.buf = append(e.buf, t...)
+	case string:
+		e.str(t)
+	case map[string]any:
+		e.buf = append(e.buf, "{}"...)
+	case []any:
+		e.buf = append(e.buf, "[]"...)
+	default:
+		return fmt.Errorf("yaml: unsupported value %T", v)
+	}
+	return nil
+}
+
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// appendNumber formats f like encoding/json, which YAML reads back as the
+// same number.
+func appendNumber(b []byte, f float64) []byte {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		b = strconv.AppendFloat(b, f, 'e', -1, 64)
+		// Clean up e-09 to e-9, as encoding/json does.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+		return b
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+// str writes s plain when it reads back as the same string and double-quoted
+// otherwise.
+func (e *encoder) str(s string) {
+	if isPlainSafe(s) {
+		e.buf = append(e.buf, s...)
+		return
+	}
+	e.buf = append(e.buf, '"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			e.buf = append(e.buf, `\"`...)
+		case '\\':
+			e.buf = append(e.buf, `\\`...)
+		case '\n':
+			e.buf = append(e.buf, `\n`...)
+		case '\t':
+			e.buf = append(e.buf, `\t`...)
+		case '\r':
+			e.buf = append(e.buf, `\r`...)
+		case 0x85:
+			e.buf = append(e.buf, `\N`...)
+		case 0x2028:
+			e.buf = append(e.buf, `\L`...)
+		case 0x2029:
+			e.buf = append(e.buf, `\P`...)
+		case 0xfeff:
+			e.buf = append(e.buf, `\uFEFF`...)
+		default:
+			if r < 0x20 || r == 0x7f {
+				e.buf = fmt.Appendf(e.buf, `\x%02x`, r)
+			} else {
+				e.buf = utf8.AppendRune(e.buf, r)
+			}
+		}
+	}
+	e.buf = append(e.buf, '"')
+}
+
+func isPlainSafe(s string) bool {
+	if s == "" || strings.IndexByte("-?:,[]{}#&*!|>'\"%@` \t", s[0]) >= 0 {
+		return false
+	}
+	if v, err := resolveScalar(s); err != nil || v != s {
+		return false
+	}
+	// YAML 1.1 readers take these for booleans.
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off":
+		return false
+	}
+	if strings.HasPrefix(s, "...") || strings.HasSuffix(s, " ") || strings.HasSuffix(s, ":") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == 0x85 || r == 0x2028 || r == 0x2029 || r == 0xfeff || r == utf8.RuneError {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+// Package yaml diffs and patches YAML documents with jsondiffgo. Documents
+// are parsed into the value model of encoding/json, so their deltas are
+// ordinary jsondiffpatch deltas that can be stored, shipped and applied like
+// those of JSON documents.
+//
+// The parser and writer are self-contained and cover the YAML 1.2 features
+// used by configuration files. Comments, anchors and the original key order
+// are not preserved: Marshal sorts mapping keys, so output is stable.
+//
+// Numbers are json.Number values, so integers and floats keep their type and
+// precision through a patch. Decode deltas shipped as JSON with
+// json.Decoder.UseNumber to keep them.
+package yaml
+
+import "github.com/jsondiffgo"
+
+// rootKey is the key jsondiffgo uses for documents whose root is not an
+// object.
+const rootKey = "_root"
+
+// Diff parses two YAML documents and returns the delta between them, as
+// jsondiffgo.Diff does for JSON.
+func Diff(a, b []byte, opts ...jsondiffgo.Option) (map[string]any, error) {
+	va, err := Unmarshal(a)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return jsondiffgo.Diff(va, vb, opts...), nil
+}
+
+// Patch applies delta to the YAML document doc and returns the result as
+// written by Marshal.
+func Patch(doc []byte, delta map[string]any) ([]byte, error) {
+	v, err := Unmarshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	obj, isObj := v.(map[string]any)
+	if !isObj {
+		// Diff returns array deltas unwrapped and other changes under
+		// "_root"; bring both to the wrapped form.
+		obj = map[string]any{rootKey: v}
+		if _, ok := delta[rootKey]; !ok && len(delta) > 0 {
+			delta = map[string]any{rootKey: delta}
+		}
+	}
+	patched, err := jsondiffgo.Patch(obj, delta)
+	if err != nil {
+		return nil, err
+	}
+	var out any = patched
+	if !isObj {
+		out = patched[rootKey]
+	}
+	return Marshal(out)
+}
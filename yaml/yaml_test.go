@@ -0,0 +1,399 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const service = `%YAML 1.2
+---
+# Service configuration
+name: api   # trailing comment
+version: 3
+ratio: 0.75
+enabled: true
+legacy: no
+owner: ~
+ports:
+- 80
+- 443
+env: &env
+  LOG_LEVEL: debug
+  "quoted key": 'it''s'
+staging:
+  env: *env
+replicas:
+  - name: a
+    zone: eu-1
+  - name: b
+    zone: us-1
+tags: [web, "edge", {tier: front}]
+limits: {cpu: 0x10, memory: 512Mi}
+motd: |
+  Welcome
+    indented
+  bye
+summary: >-
+  folded
+  text
+
+  next paragraph
+long: this plain scalar
+  continues here
+escaped: "tab\there \u00e9 \
+  joined"
+forced: !!str 123
+empty:
+nested:
+  - - 1
+    - 2
+  - []
+...
+`
+
+func TestUnmarshal_Config(t *testing.T) {
+	got, err := Unmarshal([]byte(service))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]any{"LOG_LEVEL": "debug", "quoted key": "it's"}
+	want := map[string]any{
+		"name":    "api",
+		"version": json.Number("3"),
+		"ratio":   json.Number("0.75"),
+		"enabled": true,
+		"legacy":  "no",
+		"owner":   nil,
+		"ports":   []any{json.Number("80"), json.Number("443")},
+		"env":     env,
+		"staging": map[string]any{"env": env},
+		"replicas": []any{
+			map[string]any{"name": "a", "zone": "eu-1"},
+			map[string]any{"name": "b", "zone": "us-1"},
+		},
+		"tags":    []any{"web", "edge", map[string]any{"tier": "front"}},
+		"limits":  map[string]any{"cpu": json.Number("16"), "memory": "512Mi"},
+		"motd":    "Welcome\n  indented\nbye\n",
+		"summary": "folded text\nnext paragraph",
+		"long":    "this plain scalar continues here",
+		"escaped": "tab\there é joined",
+		"forced":  "123",
+		"empty":   nil,
+		"nested":  []any{[]any{json.Number("1"), json.Number("2")}, []any{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected document.\ngot=%#v\nwant=%#v", got, want)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	for _, in := range []string{
+		"a: 1\na: 2\n",
+		"a: b: c\n",
+		"a: 1\n  b: 2\n",
+		"a:\n\t- b\n",
+		"[a, b\n",
+		"a: \"open\n",
+		"a: *missing\n",
+		"a: .inf\n",
+		"a: !custom x\n",
+		"a: 1\n---\nb: 2\n",
+		"? complex\n: key\n",
+	} {
+		var se *SyntaxError
+		if _, err := Unmarshal([]byte(in)); !errors.As(err, &se) {
+			t.Fatalf("expected a syntax error for %q, got %v", in, err)
+		}
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	doc := map[string]any{
+		"strings": []any{
+			"", "true", "1", "0x1F", "1e3", ".inf", "null", "~", "a: b", "a #b", "#x", "-", "- a",
+			" lead", "trail ", "key:", "[x]", "multi\nline", "tab\t", "é", "\x01", "quote\"s", "plain text", "...", "...0",
+		},
+		"numbers":     []any{0.0, -1.5, 1e21, 1e-7, 123456789.0},
+		"empty":       map[string]any{},
+		"list":        []any{},
+		"null":        nil,
+		"needs: ":     "quoted key",
+		"nested":      []any{[]any{1.0, []any{}}, map[string]any{"a": map[string]any{"b": []any{true}}}},
+		"interesting": map[string]any{"1": "one", "": "empty"},
+	}
+	b, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, b)
+	}
+	// Integral float64 values are written as integers.
+	doc["numbers"] = []any{json.Number("0"), json.Number("-1.5"), json.Number("1e+21"), json.Number("1e-7"), json.Number("123456789")}
+	doc["nested"].([]any)[0] = []any{json.Number("1"), []any{}}
+	if !reflect.DeepEqual(got, doc) {
+		t.Fatalf("unexpected round trip.\ngot=%#v\nwant=%#v\nyaml:\n%s", got, doc, b)
+	}
+	again, _ := Marshal(got)
+	if string(again) != string(b) {
+		t.Fatalf("output is not stable:\n%s\n---\n%s", b, again)
+	}
+}
+
+func TestMarshal_Layout(t *testing.T) {
+	b, err := Marshal(map[string]any{
+		"b": []any{map[string]any{"x": "s", "id": 1}, []any{"p", "q"}},
+		"a": map[string]any{"c": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `a:
+  c: true
+b:
+  - id: 1
+    x: s
+  - - p
+    - q
+`
+	if string(b) != want {
+		t.Fatalf("unexpected YAML.\ngot:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestDiffPatch(t *testing.T) {
+	before := []byte(`
+name: api
+replicas: 2
+env:
+  LOG_LEVEL: info
+ports: [80, 443]
+`)
+	after := []byte(`
+name: api
+replicas: 3
+env:
+  LOG_LEVEL: debug
+  TRACE: "on"
+ports: [80, 8443]
+`)
+	delta, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(delta["replicas"], []any{json.Number("2"), json.Number("3")}) {
+		t.Fatalf("unexpected delta %v", delta)
+	}
+	// Deltas are plain JSON and survive transport.
+	wire, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(wire, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Patch(before, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `env:
+  LOG_LEVEL: debug
+  TRACE: "on"
+name: api
+ports:
+  - 80
+  - 8443
+replicas: 3
+`
+	if string(got) != want {
+		t.Fatalf("unexpected patched YAML.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffPatch_NonObjectRoot(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"- a\n- b\n", "- a\n- c\n- d\n"},
+		{"hello\n", "world\n"},
+		{"- a\n", "a: 1\n"},
+	} {
+		delta, err := Diff([]byte(tc[0]), []byte(tc[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Patch([]byte(tc[0]), delta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc[1] {
+			t.Fatalf("unexpected patched YAML for %q. got=%q want=%q", tc[0], got, tc[1])
+		}
+	}
+}
+
+func TestPatch_KeepsUntouchedNumbers(t *testing.T) {
+	doc := []byte("big: 9007199254740993\ncount: 1\nf: 1.0\nx: 1.5e3\n")
+	got, err := Patch(doc, map[string]any{"count": []any{json.Number("1"), json.Number("2")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "big: 9007199254740993\ncount: 2\nf: 1.0\nx: 1.5e3\n"
+	if string(got) != want {
+		t.Fatalf("unexpected patched YAML.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Floats and integers survive a delta shipped as JSON and decoded with
+	// UseNumber.
+	delta, err := Diff(doc, []byte("big: 9007199254740995\ncount: 1\nf: 2.0\nx: 1.5e3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire, err := json.Marshal(delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(wire))
+	dec.UseNumber()
+	var decoded map[string]any
+	if err := dec.Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = Patch(doc, decoded); err != nil {
+		t.Fatal(err)
+	}
+	want = "big: 9007199254740995\ncount: 1\nf: 2.0\nx: 1.5e3\n"
+	if string(got) != want {
+		t.Fatalf("unexpected patched YAML.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnmarshal_Numbers(t *testing.T) {
+	got, err := Unmarshal([]byte("[+1, 007, 0x1F, 0o17, .5, -1., +2.5e3, !!float 3, !!int '4']\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		json.Number("1"), json.Number("7"), json.Number("31"), json.Number("15"),
+		json.Number("0.5"), json.Number("-1.0"), json.Number("2.5e3"), json.Number("3.0"), json.Number("4"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected numbers.\ngot=%#v\nwant=%#v", got, want)
+	}
+}
+
+// TestConformance reads the documents in testdata/valid and compares them
+// with the JSON file of the same name, and checks that those in
+// testdata/invalid are rejected with a SyntaxError.
+func TestConformance(t *testing.T) {
+	valid, err := filepath.Glob("testdata/valid/*.yaml")
+	if err != nil || len(valid) == 0 {
+		t.Fatalf("no valid documents: %v", err)
+	}
+	for _, path := range valid {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		wantJSON, err := os.ReadFile(strings.TrimSuffix(path, ".yaml") + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, w := comparable(t, gotJSON), comparable(t, wantJSON); !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: unexpected document.\ngot=%#v\nwant=%#v", path, g, w)
+		}
+	}
+
+	invalid, err := filepath.Glob("testdata/invalid/*.yaml")
+	if err != nil || len(invalid) == 0 {
+		t.Fatalf("no invalid documents: %v", err)
+	}
+	for _, path := range invalid {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var se *SyntaxError
+		if _, err := Unmarshal(data); !errors.As(err, &se) {
+			t.Errorf("%s: expected a syntax error, got %v", path, err)
+		}
+	}
+}
+
+// comparable decodes JSON with integers kept as json.Number and floats
+// turned into float64, so that 1e06 and 1000000.0 compare equal but 1 and
+// 1.0 do not.
+func comparable(t *testing.T, data []byte) any {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("%v in %s", err, data)
+	}
+	var walk func(any) any
+	walk = func(v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, x := range t {
+				t[k] = walk(x)
+			}
+		case []any:
+			for i, x := range t {
+				t[i] = walk(x)
+			}
+		case json.Number:
+			if strings.ContainsAny(string(t), ".eE") {
+				f, _ := t.Float64()
+				return f
+			}
+		}
+		return v
+	}
+	return walk(v)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte(service))
+	f.Add([]byte("- [a, {b: c}]\n- |+\n  x\n\n"))
+	seeds, _ := filepath.Glob("testdata/*/*.yaml")
+	for _, path := range seeds {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		b, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		got, err := Unmarshal(b)
+		if err != nil {
+			t.Fatalf("reading back %q: %v", b, err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("unexpected round trip of %q.\ngot=%#v\nwant=%#v", b, got, v)
+		}
+	})
+}